@@ -0,0 +1,253 @@
+// Package analytics computes technical indicators over daily price series. Indicators return a line per output
+// aligned with the bars they were computed from, NaN until enough bars have been seen for a value.
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"stock_ticker/api"
+)
+
+var (
+	ErrUnknownIndicator = errors.New("unknown indicator")
+	ErrInvalidParams    = errors.New("invalid indicator parameters")
+)
+
+// Bar is the OHLCV of a day as floating point numbers
+type Bar struct {
+	Date   string
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// Bars converts prices, latest first as they are stored, to bars oldest first as indicators are computed
+func Bars(prices []*api.DailyPrice) []Bar {
+	bars := make([]Bar, len(prices))
+
+	for i, price := range prices {
+		bars[len(prices)-1-i] = Bar{
+			Date:   price.Day,
+			Open:   price.Price.Open.Float64(),
+			High:   price.Price.High.Float64(),
+			Low:    price.Price.Low.Float64(),
+			Close:  price.Price.Close.Float64(),
+			Volume: float64(price.Price.Volume),
+		}
+	}
+
+	return bars
+}
+
+// Closes returns the closing prices of the bars
+func Closes(bars []Bar) []float64 {
+	closes := make([]float64, len(bars))
+	for i, bar := range bars {
+		closes[i] = bar.Close
+	}
+
+	return closes
+}
+
+// Params are the parameters of an indicator, zero values take the defaults of the indicator
+type Params struct {
+	// Period is the number of bars each value is computed over
+	Period int
+	// Fast, Slow and Signal are the periods of the MACD averages
+	Fast   int
+	Slow   int
+	Signal int
+	// K is the number of standard deviations of the Bollinger Bands
+	K float64
+}
+
+// indicator describes how to compute an indicator and how many bars it needs before its first value
+type indicator struct {
+	defaults Params
+	lines    []string
+	lookback func(p Params) int
+	compute  func(bars []Bar, p Params) [][]float64
+}
+
+func period(p Params) int {
+	return p.Period - 1
+}
+
+var indicators = map[string]indicator{
+	"sma": {
+		defaults: Params{Period: 20},
+		lines:    []string{"value"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{SMA(Closes(bars), p.Period)}
+		},
+	},
+	"ema": {
+		defaults: Params{Period: 20},
+		lines:    []string{"value"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{EMA(Closes(bars), p.Period)}
+		},
+	},
+	"wma": {
+		defaults: Params{Period: 20},
+		lines:    []string{"value"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{WMA(Closes(bars), p.Period)}
+		},
+	},
+	"rsi": {
+		defaults: Params{Period: 14},
+		lines:    []string{"value"},
+		lookback: func(p Params) int {
+			return p.Period
+		},
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{RSI(Closes(bars), p.Period)}
+		},
+	},
+	"macd": {
+		defaults: Params{Fast: 12, Slow: 26, Signal: 9},
+		lines:    []string{"macd", "signal", "histogram"},
+		lookback: func(p Params) int {
+			return p.Slow + p.Signal - 2
+		},
+		compute: func(bars []Bar, p Params) [][]float64 {
+			macd, signal, histogram := MACD(Closes(bars), p.Fast, p.Slow, p.Signal)
+
+			return [][]float64{macd, signal, histogram}
+		},
+	},
+	"bbands": {
+		defaults: Params{Period: 20, K: 2},
+		lines:    []string{"middle", "upper", "lower"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			middle, upper, lower := Bollinger(Closes(bars), p.Period, p.K)
+
+			return [][]float64{middle, upper, lower}
+		},
+	},
+	"atr": {
+		defaults: Params{Period: 14},
+		lines:    []string{"value"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{ATR(bars, p.Period)}
+		},
+	},
+	"vwap": {
+		defaults: Params{Period: 20},
+		lines:    []string{"value"},
+		lookback: period,
+		compute: func(bars []Bar, p Params) [][]float64 {
+			return [][]float64{VWAP(bars, p.Period)}
+		},
+	},
+}
+
+// Names returns the names of the indicators that can be computed
+func Names() []string {
+	names := make([]string, 0, len(indicators))
+	for name := range indicators {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookback returns the number of bars the named indicator needs before its first value
+func Lookback(name string, p Params) (int, error) {
+	ind, p, err := resolve(name, p)
+	if err != nil {
+		return 0, err
+	}
+
+	return ind.lookback(p), nil
+}
+
+// Point is the value of every line of an indicator on a day
+type Point struct {
+	Date   string
+	Values map[string]float64
+}
+
+// Compute computes the named indicator over the bars, oldest first. It returns a point for every bar from the first
+// one all the lines of the indicator have a value for.
+func Compute(name string, bars []Bar, p Params) ([]Point, error) {
+	ind, p, err := resolve(name, p)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := ind.compute(bars, p)
+
+	points := make([]Point, 0, len(bars))
+
+	for i, bar := range bars {
+		values := make(map[string]float64, len(lines))
+
+		for j, line := range lines {
+			if math.IsNaN(line[i]) {
+				break
+			}
+
+			values[ind.lines[j]] = line[i]
+		}
+
+		if len(values) != len(lines) {
+			continue
+		}
+
+		points = append(points, Point{Date: bar.Date, Values: values})
+	}
+
+	return points, nil
+}
+
+// resolve returns the named indicator and the parameters with its defaults filled in
+func resolve(name string, p Params) (indicator, Params, error) {
+	ind, ok := indicators[name]
+	if !ok {
+		return indicator{}, p, fmt.Errorf("%w %q", ErrUnknownIndicator, name)
+	}
+
+	if p.Period == 0 {
+		p.Period = ind.defaults.Period
+	}
+
+	if p.Fast == 0 {
+		p.Fast = ind.defaults.Fast
+	}
+
+	if p.Slow == 0 {
+		p.Slow = ind.defaults.Slow
+	}
+
+	if p.Signal == 0 {
+		p.Signal = ind.defaults.Signal
+	}
+
+	if p.K == 0 {
+		p.K = ind.defaults.K
+	}
+
+	if p.Period < 0 || p.Fast < 0 || p.Slow < 0 || p.Signal < 0 || p.K < 0 {
+		return indicator{}, p, fmt.Errorf("%w: periods and k must be positive", ErrInvalidParams)
+	}
+
+	if name == "macd" && p.Fast >= p.Slow {
+		return indicator{}, p, fmt.Errorf("%w: the fast period must be shorter than the slow period", ErrInvalidParams)
+	}
+
+	return ind, p, nil
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+)
+
+func TestBars(t *testing.T) {
+	bars := Bars([]*api.DailyPrice{
+		{Day: "2022-04-01", Price: &api.Price{Open: api.MustParseDecimal("309.3700"), High: api.MustParseDecimal("310.1300"), Low: api.MustParseDecimal("305.5400"), Close: api.MustParseDecimal("309.4200"), Volume: 27110529}},
+		{Day: "2022-03-31", Price: &api.Price{Open: api.MustParseDecimal("313.9000"), High: api.MustParseDecimal("315.1400"), Low: api.MustParseDecimal("307.8900"), Close: api.MustParseDecimal("308.3100"), Volume: 33422070}},
+	})
+
+	assert.Equal(t, []Bar{
+		{Date: "2022-03-31", Open: 313.9, High: 315.14, Low: 307.89, Close: 308.31, Volume: 33422070},
+		{Date: "2022-04-01", Open: 309.37, High: 310.13, Low: 305.54, Close: 309.42, Volume: 27110529},
+	}, bars)
+}
+
+func TestCompute(t *testing.T) {
+	bars := []Bar{
+		{Date: "2022-03-29", Close: 2},
+		{Date: "2022-03-30", Close: 4},
+		{Date: "2022-03-31", Close: 4},
+		{Date: "2022-04-01", Close: 6},
+	}
+
+	points, err := Compute("sma", bars, Params{Period: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []Point{
+		{Date: "2022-03-31", Values: map[string]float64{"value": 10.0 / 3}},
+		{Date: "2022-04-01", Values: map[string]float64{"value": 14.0 / 3}},
+	}, points)
+
+	points, err = Compute("bbands", bars, Params{Period: 4, K: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []Point{
+		{Date: "2022-04-01", Values: map[string]float64{"middle": 4, "upper": 4 + math.Sqrt(2), "lower": 4 - math.Sqrt(2)}},
+	}, points)
+
+	// not enough bars for a value
+	points, err = Compute("rsi", bars, Params{})
+	assert.NoError(t, err)
+	assert.Empty(t, points)
+
+	_, err = Compute("obv", bars, Params{})
+	assert.ErrorIs(t, err, ErrUnknownIndicator)
+
+	_, err = Compute("macd", bars, Params{Fast: 26, Slow: 12})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+}
+
+func TestLookback(t *testing.T) {
+	lookback, err := Lookback("macd", Params{})
+	assert.NoError(t, err)
+	assert.Equal(t, 33, lookback)
+
+	lookback, err = Lookback("rsi", Params{Period: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, lookback)
+}
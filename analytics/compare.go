@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+)
+
+// Align returns the days every series has a bar on, oldest first, and the closes of each series on those days
+func Align(series map[string][]Bar) ([]string, map[string][]float64) {
+	counts := make(map[string]int)
+
+	for _, bars := range series {
+		for _, bar := range bars {
+			counts[bar.Date]++
+		}
+	}
+
+	days := make([]string, 0, len(counts))
+	for day, count := range counts {
+		if count == len(series) {
+			days = append(days, day)
+		}
+	}
+
+	// the api format sorts chronologically
+	sort.Strings(days)
+
+	index := make(map[string]int, len(days))
+	for i, day := range days {
+		index[day] = i
+	}
+
+	closes := make(map[string][]float64, len(series))
+
+	for name, bars := range series {
+		aligned := make([]float64, len(days))
+
+		for _, bar := range bars {
+			if i, ok := index[bar.Date]; ok {
+				aligned[i] = bar.Close
+			}
+		}
+
+		closes[name] = aligned
+	}
+
+	return days, closes
+}
+
+// Rebase returns the values scaled so the first one is base, e.g. 100 to compare performance
+func Rebase(values []float64, base float64) []float64 {
+	rebased := make([]float64, len(values))
+	if len(values) == 0 || values[0] == 0 {
+		return rebased
+	}
+
+	for i, value := range values {
+		rebased[i] = value / values[0] * base
+	}
+
+	return rebased
+}
+
+// Returns returns the simple returns from each value to the next
+func Returns(values []float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+
+	returns := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		returns[i-1] = values[i]/values[i-1] - 1
+	}
+
+	return returns
+}
+
+// Correlation returns the Pearson correlation of two series of the same length, NaN when either does not vary
+func Correlation(a, b []float64) float64 {
+	deviation := math.Sqrt(covariance(a, a) * covariance(b, b))
+	if len(a) < 2 || len(a) != len(b) || deviation == 0 {
+		return math.NaN()
+	}
+
+	return covariance(a, b) / deviation
+}
+
+// Beta returns the covariance of the returns with the benchmark returns over the variance of the benchmark returns,
+// NaN when the benchmark does not vary
+func Beta(returns, benchmark []float64) float64 {
+	variance := covariance(benchmark, benchmark)
+	if len(returns) < 2 || len(returns) != len(benchmark) || variance == 0 {
+		return math.NaN()
+	}
+
+	return covariance(returns, benchmark) / variance
+}
+
+// covariance returns the sample covariance of two series of the same length
+func covariance(a, b []float64) float64 {
+	if len(a) < 2 || len(a) != len(b) {
+		return 0
+	}
+
+	meanA, meanB := mean(a), mean(b)
+
+	var sum float64
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+
+	return sum / float64(len(a)-1)
+}
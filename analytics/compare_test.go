@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlign(t *testing.T) {
+	days, closes := Align(map[string][]Bar{
+		"MSFT": {{Date: "2022-03-30", Close: 313.86}, {Date: "2022-03-31", Close: 308.31}, {Date: "2022-04-01", Close: 309.42}},
+		// no price on 2022-03-31
+		"IBM": {{Date: "2022-03-29", Close: 131.94}, {Date: "2022-03-30", Close: 131.36}, {Date: "2022-04-01", Close: 130.15}},
+	})
+
+	assert.Equal(t, []string{"2022-03-30", "2022-04-01"}, days)
+	assert.Equal(t, map[string][]float64{
+		"MSFT": {313.86, 309.42},
+		"IBM":  {131.36, 130.15},
+	}, closes)
+}
+
+func TestRebase(t *testing.T) {
+	assert.InDeltaSlice(t, []float64{100, 110, 95}, Rebase([]float64{20, 22, 19}, 100), 1e-9)
+	assert.Equal(t, []float64{0, 0}, Rebase([]float64{0, 1}, 100))
+}
+
+func TestCorrelation(t *testing.T) {
+	a := []float64{1, 2, 3, 4}
+
+	assert.InDelta(t, 1, Correlation(a, []float64{2, 4, 6, 8}), 1e-9)
+	assert.InDelta(t, -1, Correlation(a, []float64{4, 3, 2, 1}), 1e-9)
+	// sum of the products of the deviations is 0
+	assert.InDelta(t, 0, Correlation(a, []float64{1, -1, -1, 1}), 1e-9)
+	assert.True(t, math.IsNaN(Correlation(a, []float64{1, 1, 1, 1})))
+}
+
+func TestBeta(t *testing.T) {
+	benchmark := []float64{0.01, -0.02, 0.03, 0.00}
+
+	assert.InDelta(t, 2, Beta([]float64{0.02, -0.04, 0.06, 0.00}, benchmark), 1e-9)
+	// a constant offset does not change beta
+	assert.InDelta(t, 0.5, Beta([]float64{0.015, 0, 0.025, 0.01}, benchmark), 1e-9)
+	assert.True(t, math.IsNaN(Beta(benchmark, []float64{0.01, 0.01, 0.01, 0.01})))
+}
+
+func TestReturns(t *testing.T) {
+	returns := Returns([]float64{100, 110, 99})
+
+	assert.InDeltaSlice(t, []float64{0.1, -0.1}, returns, 1e-9)
+	assert.Nil(t, Returns([]float64{100}))
+}
@@ -0,0 +1,243 @@
+package analytics
+
+import (
+	"math"
+)
+
+// SMA returns the simple moving average of the values over period
+func SMA(values []float64, period int) []float64 {
+	out := nans(len(values))
+	if period < 1 {
+		return out
+	}
+
+	var sum float64
+
+	for i, value := range values {
+		sum += value
+		if i >= period {
+			sum -= values[i-period]
+		}
+
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+
+	return out
+}
+
+// EMA returns the exponential moving average of the values over period, weighting each value by 2 / (period + 1).
+// It is seeded with the simple moving average of the first period values, leading NaN values are skipped so an
+// average of an indicator can be taken.
+func EMA(values []float64, period int) []float64 {
+	out := nans(len(values))
+	if period < 1 {
+		return out
+	}
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+
+	if len(values)-start < period {
+		return out
+	}
+
+	var sum float64
+	for _, value := range values[start : start+period] {
+		sum += value
+	}
+
+	alpha := 2 / float64(period+1)
+
+	prev := sum / float64(period)
+	out[start+period-1] = prev
+
+	for i := start + period; i < len(values); i++ {
+		prev += alpha * (values[i] - prev)
+		out[i] = prev
+	}
+
+	return out
+}
+
+// WMA returns the linearly weighted moving average of the values over period, the latest value weighing period
+// times the earliest
+func WMA(values []float64, period int) []float64 {
+	out := nans(len(values))
+	if period < 1 {
+		return out
+	}
+
+	weights := float64(period*(period+1)) / 2
+
+	for i := period - 1; i < len(values); i++ {
+		var sum float64
+		for j := 0; j < period; j++ {
+			sum += values[i-period+1+j] * float64(j+1)
+		}
+
+		out[i] = sum / weights
+	}
+
+	return out
+}
+
+// RSI returns the relative strength index of the values over period with Wilder's smoothing. The first value is
+// from the simple averages of the gains and losses of the first period changes.
+func RSI(values []float64, period int) []float64 {
+	out := nans(len(values))
+	if period < 1 || len(values) <= period {
+		return out
+	}
+
+	var gain, loss float64
+
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+
+	gain /= float64(period)
+	loss /= float64(period)
+	out[period] = rsi(gain, loss)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+
+		gain = (gain*float64(period-1) + math.Max(change, 0)) / float64(period)
+		loss = (loss*float64(period-1) + math.Max(-change, 0)) / float64(period)
+
+		out[i] = rsi(gain, loss)
+	}
+
+	return out
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACD returns the difference between the fast and slow exponential moving averages of the values, the signal
+// line as the exponential moving average of that difference and the histogram as the difference of the two
+func MACD(values []float64, fast, slow, signal int) ([]float64, []float64, []float64) {
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+
+	macd := make([]float64, len(values))
+	for i := range values {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	signalLine := EMA(macd, signal)
+
+	histogram := make([]float64, len(values))
+	for i := range values {
+		histogram[i] = macd[i] - signalLine[i]
+	}
+
+	return macd, signalLine, histogram
+}
+
+// Bollinger returns the simple moving average of the values over period as the middle band and the bands k
+// population standard deviations above and below it
+func Bollinger(values []float64, period int, k float64) ([]float64, []float64, []float64) {
+	middle := SMA(values, period)
+	upper, lower := nans(len(values)), nans(len(values))
+
+	for i := period - 1; i < len(values) && period > 0; i++ {
+		var squares float64
+		for _, value := range values[i-period+1 : i+1] {
+			squares += (value - middle[i]) * (value - middle[i])
+		}
+
+		deviation := math.Sqrt(squares / float64(period))
+
+		upper[i] = middle[i] + k*deviation
+		lower[i] = middle[i] - k*deviation
+	}
+
+	return middle, upper, lower
+}
+
+// ATR returns the average true range of the bars over period with Wilder's smoothing. The true range of a bar is
+// the largest of its range and its distances from the previous close, the first bar having only its range.
+func ATR(bars []Bar, period int) []float64 {
+	out := nans(len(bars))
+	if period < 1 || len(bars) < period {
+		return out
+	}
+
+	trueRanges := make([]float64, len(bars))
+
+	for i, bar := range bars {
+		trueRanges[i] = bar.High - bar.Low
+
+		if i > 0 {
+			prevClose := bars[i-1].Close
+			trueRanges[i] = math.Max(trueRanges[i], math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+		}
+	}
+
+	var sum float64
+	for _, trueRange := range trueRanges[:period] {
+		sum += trueRange
+	}
+
+	atr := sum / float64(period)
+	out[period-1] = atr
+
+	for i := period; i < len(bars); i++ {
+		atr = (atr*float64(period-1) + trueRanges[i]) / float64(period)
+		out[i] = atr
+	}
+
+	return out
+}
+
+// VWAP returns the volume weighted average of the typical price, (high + low + close) / 3, of the bars over period.
+// Periods without volume have no value.
+func VWAP(bars []Bar, period int) []float64 {
+	out := nans(len(bars))
+	if period < 1 {
+		return out
+	}
+
+	var value, volume float64
+
+	for i, bar := range bars {
+		value += (bar.High + bar.Low + bar.Close) / 3 * bar.Volume
+		volume += bar.Volume
+
+		if i >= period {
+			first := bars[i-period]
+			value -= (first.High + first.Low + first.Close) / 3 * first.Volume
+			volume -= first.Volume
+		}
+
+		if i >= period-1 && volume > 0 {
+			out[i] = value / volume
+		}
+	}
+
+	return out
+}
+
+// nans returns n NaN values
+func nans(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+
+	return out
+}
@@ -0,0 +1,112 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the closes of the StockCharts examples, the expected values are computed without the intermediate rounding of
+// their spreadsheets so some differ in the second decimal place
+var (
+	_averageCloses = []float64{22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29, 22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63, 23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17}
+	_rsiCloses     = []float64{44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64, 46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57, 43.42, 42.66, 43.13}
+)
+
+// assertLine checks the line is NaN for the first warmUp values and then matches expected to 2 decimal places
+func assertLine(t *testing.T, expected []float64, warmUp int, line []float64) {
+	t.Helper()
+
+	assert.Len(t, line, warmUp+len(expected))
+
+	for i := 0; i < warmUp; i++ {
+		assert.True(t, math.IsNaN(line[i]), "value %d should be NaN, got %v", i, line[i])
+	}
+
+	for i, value := range expected {
+		assert.InDelta(t, value, line[warmUp+i], 0.005, "value %d", warmUp+i)
+	}
+}
+
+func TestSMA(t *testing.T) {
+	assertLine(t, []float64{22.22, 22.21, 22.23, 22.26, 22.30, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21, 23.38, 23.52, 23.65, 23.71, 23.68, 23.61, 23.50, 23.43, 23.28, 23.13}, 9, SMA(_averageCloses, 10))
+}
+
+func TestEMA(t *testing.T) {
+	assertLine(t, []float64{22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34, 23.43, 23.51, 23.53, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92}, 9, EMA(_averageCloses, 10))
+
+	// leading NaN values are skipped
+	assertLine(t, []float64{2, 3}, 3, EMA([]float64{math.NaN(), 1, 2, 3, 4}, 3))
+}
+
+func TestWMA(t *testing.T) {
+	assertLine(t, []float64{14.0 / 6, 20.0 / 6, 26.0 / 6}, 2, WMA([]float64{1, 2, 3, 4, 5}, 3))
+}
+
+func TestRSI(t *testing.T) {
+	assertLine(t, []float64{70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34, 54.67, 50.39, 40.02, 41.49, 41.90, 45.50, 37.32, 33.09, 37.79}, 14, RSI(_rsiCloses, 14))
+
+	// no losses
+	assertLine(t, []float64{100}, 2, RSI([]float64{1, 2, 3}, 2))
+}
+
+func TestMACD(t *testing.T) {
+	// moving averages of a straight line lag it by (period - 1) / 2 so the difference is constant
+	values := make([]float64, 50)
+	for i := range values {
+		values[i] = float64(i)
+	}
+
+	macd, signal, histogram := MACD(values, 12, 26, 9)
+
+	assertLine(t, repeat(7, 25), 25, macd)
+	assertLine(t, repeat(7, 17), 33, signal)
+	assertLine(t, repeat(0, 17), 33, histogram)
+}
+
+func TestBollinger(t *testing.T) {
+	// mean 5 and standard deviation 2
+	middle, upper, lower := Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+
+	assertLine(t, []float64{5}, 7, middle)
+	assertLine(t, []float64{9}, 7, upper)
+	assertLine(t, []float64{1}, 7, lower)
+}
+
+func TestATR(t *testing.T) {
+	bars := []Bar{
+		{High: 10, Low: 8, Close: 9},
+		// gap up so the distance from the previous close is the true range
+		{High: 13, Low: 11, Close: 12},
+		{High: 12.5, Low: 11.5, Close: 12},
+		{High: 12, Low: 9, Close: 10},
+	}
+
+	// true ranges 2, 4, 1, 3
+	assertLine(t, []float64{7.0 / 3, (7.0/3*2 + 3) / 3}, 2, ATR(bars, 3))
+}
+
+func TestVWAP(t *testing.T) {
+	bars := []Bar{
+		{High: 12, Low: 9, Close: 9, Volume: 100},
+		{High: 13, Low: 11, Close: 12, Volume: 300},
+		{High: 12, Low: 12, Close: 12, Volume: 0},
+		{High: 12, Low: 12, Close: 12, Volume: 0},
+	}
+
+	// typical prices 10, 12 and 12
+	assertLine(t, []float64{(10*100 + 12*300) / 400.0, 12}, 1, VWAP(bars[:3], 2))
+
+	// no volume over the period
+	assert.True(t, math.IsNaN(VWAP(bars, 2)[3]))
+}
+
+func repeat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+
+	return values
+}
@@ -0,0 +1,201 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// TradingDays is the number of trading days in a year, daily statistics are annualized with it
+const TradingDays = 252
+
+// ErrTooFewBars is returned when there are not enough bars for a return
+var ErrTooFewBars = errors.New("at least 2 bars are needed")
+
+// Percentiles are the percentiles of the closes reported in the statistics
+var Percentiles = []float64{5, 25, 50, 75, 95}
+
+// Drawdown is the largest fall of the close from a peak to a later trough, Depth is the fall as a fraction of the peak
+type Drawdown struct {
+	Depth  float64
+	Peak   string
+	Trough string
+}
+
+// Extreme is the highest or lowest price of a range of bars and the day it was reached
+type Extreme struct {
+	Date  string
+	Value float64
+}
+
+// Statistics are the return and risk statistics of a series of bars
+type Statistics struct {
+	From  string
+	To    string
+	Count int
+
+	// SimpleReturn and LogReturn are the returns from the first to the last close
+	SimpleReturn float64
+	LogReturn    float64
+	// MeanReturn and MeanLogReturn are the mean returns from one bar to the next
+	MeanReturn    float64
+	MeanLogReturn float64
+
+	// Volatility is the annualized sample standard deviation of the log returns
+	Volatility float64
+	// Sharpe and Sortino are annualized ratios of the mean excess return to the standard deviation of the returns and
+	// of the returns below the risk free rate, NaN when the returns do not vary or never fall below it
+	Sharpe  float64
+	Sortino float64
+
+	MaxDrawdown Drawdown
+
+	// Closes are the closes at each of Percentiles
+	Closes map[float64]float64
+}
+
+// Summarize computes the statistics of the bars, oldest first. periodsPerYear is the number of bars in a year used
+// to annualize and riskFree the annual risk free rate as a fraction.
+func Summarize(bars []Bar, periodsPerYear int, riskFree float64) (Statistics, error) {
+	if len(bars) < 2 {
+		return Statistics{}, ErrTooFewBars
+	}
+
+	first, last := bars[0], bars[len(bars)-1]
+
+	stats := Statistics{
+		From:         first.Date,
+		To:           last.Date,
+		Count:        len(bars),
+		SimpleReturn: last.Close/first.Close - 1,
+		LogReturn:    math.Log(last.Close / first.Close),
+		MaxDrawdown:  MaxDrawdown(bars),
+		Closes:       make(map[float64]float64, len(Percentiles)),
+		Sharpe:       math.NaN(),
+		Sortino:      math.NaN(),
+	}
+
+	returns := make([]float64, 0, len(bars)-1)
+	logReturns := make([]float64, 0, len(bars)-1)
+
+	for i := 1; i < len(bars); i++ {
+		returns = append(returns, bars[i].Close/bars[i-1].Close-1)
+		logReturns = append(logReturns, math.Log(bars[i].Close/bars[i-1].Close))
+	}
+
+	stats.MeanReturn = mean(returns)
+	stats.MeanLogReturn = mean(logReturns)
+
+	annualize := math.Sqrt(float64(periodsPerYear))
+	stats.Volatility = stdDev(logReturns) * annualize
+
+	// the risk free return of one bar
+	target := riskFree / float64(periodsPerYear)
+	excess := stats.MeanReturn - target
+
+	if deviation := stdDev(returns); deviation > 0 {
+		stats.Sharpe = excess / deviation * annualize
+	}
+
+	var downside float64
+	for _, r := range returns {
+		if r < target {
+			downside += (r - target) * (r - target)
+		}
+	}
+
+	if downside > 0 {
+		stats.Sortino = excess / math.Sqrt(downside/float64(len(returns))) * annualize
+	}
+
+	closes := Closes(bars)
+	sort.Float64s(closes)
+
+	for _, p := range Percentiles {
+		stats.Closes[p] = Percentile(closes, p)
+	}
+
+	return stats, nil
+}
+
+// MaxDrawdown returns the largest fall of the close of the bars, oldest first, from a peak to a later trough
+func MaxDrawdown(bars []Bar) Drawdown {
+	var drawdown Drawdown
+	var peak Bar
+
+	for i, bar := range bars {
+		if i == 0 || bar.Close > peak.Close {
+			peak = bar
+
+			continue
+		}
+
+		if depth := bar.Close/peak.Close - 1; depth < drawdown.Depth {
+			drawdown = Drawdown{Depth: depth, Peak: peak.Date, Trough: bar.Date}
+		}
+	}
+
+	return drawdown
+}
+
+// Range returns the highest high and lowest low of the bars on or after from, a day in the api format
+func Range(bars []Bar, from string) (Extreme, Extreme) {
+	var high, low Extreme
+
+	for _, bar := range bars {
+		if bar.Date < from {
+			continue
+		}
+
+		if high.Date == "" || bar.High > high.Value {
+			high = Extreme{Date: bar.Date, Value: bar.High}
+		}
+
+		if low.Date == "" || bar.Low < low.Value {
+			low = Extreme{Date: bar.Date, Value: bar.Low}
+		}
+	}
+
+	return high, low
+}
+
+// Percentile returns the p-th percentile of the sorted values interpolating linearly between the closest ranks
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum / float64(len(values))
+}
+
+// stdDev returns the sample standard deviation of the values
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	m := mean(values)
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - m) * (value - m)
+	}
+
+	return math.Sqrt(squares / float64(len(values)-1))
+}
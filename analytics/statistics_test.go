@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	bars := []Bar{
+		{Date: "2022-03-29", Close: 100},
+		{Date: "2022-03-30", Close: 110},
+		{Date: "2022-03-31", Close: 99},
+		{Date: "2022-04-01", Close: 121},
+	}
+
+	stats, err := Summarize(bars, TradingDays, 0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "2022-03-29", stats.From)
+	assert.Equal(t, "2022-04-01", stats.To)
+	assert.Equal(t, 4, stats.Count)
+
+	assert.InDelta(t, 0.21, stats.SimpleReturn, 1e-9)
+	assert.InDelta(t, math.Log(1.21), stats.LogReturn, 1e-9)
+	assert.InDelta(t, 0.074074, stats.MeanReturn, 1e-6)
+	assert.InDelta(t, math.Log(1.21)/3, stats.MeanLogReturn, 1e-9)
+
+	assert.InDelta(t, 2.468002, stats.Volatility, 1e-6)
+	assert.InDelta(t, 7.228766, stats.Sharpe, 1e-6)
+	// the only return below zero is the fall of 10%
+	assert.InDelta(t, 20.367003, stats.Sortino, 1e-6)
+
+	assert.Equal(t, "2022-03-30", stats.MaxDrawdown.Peak)
+	assert.Equal(t, "2022-03-31", stats.MaxDrawdown.Trough)
+	assert.InDelta(t, -0.1, stats.MaxDrawdown.Depth, 1e-9)
+
+	expected := map[float64]float64{5: 99.15, 25: 99.75, 50: 105, 75: 112.75, 95: 119.35}
+	for p, value := range expected {
+		assert.InDelta(t, value, stats.Closes[p], 1e-9, "percentile %v", p)
+	}
+}
+
+func TestSummarize_undefinedRatios(t *testing.T) {
+	// rising by the same return every day leaves nothing to divide by
+	stats, err := Summarize([]Bar{{Close: 100}, {Close: 110}, {Close: 121}}, TradingDays, 0)
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(stats.Sharpe))
+	assert.True(t, math.IsNaN(stats.Sortino))
+	assert.Equal(t, Drawdown{}, stats.MaxDrawdown)
+
+	_, err = Summarize([]Bar{{Close: 100}}, TradingDays, 0)
+	assert.ErrorIs(t, err, ErrTooFewBars)
+}
+
+func TestRange(t *testing.T) {
+	bars := []Bar{
+		{Date: "2021-03-31", High: 200, Low: 50},
+		{Date: "2021-04-01", High: 120, Low: 90},
+		{Date: "2022-03-31", High: 130, Low: 95},
+		{Date: "2022-04-01", High: 125, Low: 80},
+	}
+
+	high, low := Range(bars, "2021-04-01")
+	assert.Equal(t, Extreme{Date: "2022-03-31", Value: 130}, high)
+	assert.Equal(t, Extreme{Date: "2022-04-01", Value: 80}, low)
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 7.0, Percentile([]float64{7}, 50))
+	assert.Equal(t, 4.0, Percentile([]float64{1, 2, 3, 4}, 100))
+	assert.Equal(t, 1.0, Percentile([]float64{1, 2, 3, 4}, 0))
+	assert.True(t, math.IsNaN(Percentile(nil, 50)))
+}
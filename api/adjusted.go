@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+)
+
+// _adjustedScale is the number of decimal places of adjusted prices, as the api quotes its adjusted close
+const _adjustedScale = 4
+
+// GetAdjustedSeries gets the daily time series of symbol along with its adjusted close, dividends and splits
+func (c *Client) GetAdjustedSeries(ctx context.Context, symbol string, size OutputSize) (*AdjustedResponse, error) {
+	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_DAILY_ADJUSTED&symbol=%s&datatype=json&outputsize=%s", c.options.baseURL, c.options.apiKey, url.QueryEscape(symbol), size)
+
+	respBody, err := c.performRequest(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = respBody.Close()
+	}()
+
+	body, err := ioutil.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var res AdjustedResponse
+
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if err = checkResponse(&res.Errors); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Split separates the raw prices from the corporate actions. The factor of a dividend is 1 - dividend / close of
+// the day before and of a split 1 / split coefficient. A dividend on the first day of the series cannot be factored
+// and is left out, so it does not replace the action stored by a longer series.
+func (r *AdjustedResponse) Split() (*JSONResponse, CorporateActions, error) {
+	raw := &JSONResponse{
+		MetaData:    r.MetaData,
+		DailyPrices: make(TimeSeriesDaily, len(r.DailyPrices)),
+	}
+
+	actions := make(CorporateActions)
+
+	days := make([]string, 0, len(r.DailyPrices))
+	for day := range r.DailyPrices {
+		days = append(days, day)
+	}
+
+	// the api format sorts chronologically
+	sort.Strings(days)
+
+	var prevClose float64
+
+	for _, day := range days {
+		price := r.DailyPrices[day]
+
+		raw.DailyPrices[day] = Price{
+			Open:   price.Open,
+			High:   price.High,
+			Low:    price.Low,
+			Close:  price.Close,
+			Volume: price.Volume,
+		}
+
+		if price.SplitCoefficient.IsZero() {
+			return nil, nil, fmt.Errorf("invalid split coefficient of %s: %s", day, price.SplitCoefficient)
+		}
+
+		dividend, split := price.DividendAmount.Float64(), price.SplitCoefficient.Float64()
+
+		if (dividend != 0 || split != 1) && (dividend == 0 || prevClose != 0) {
+			factor := 1 / split
+			if dividend != 0 {
+				factor *= 1 - dividend/prevClose
+			}
+
+			actions[day] = CorporateAction{
+				DividendAmount:   price.DividendAmount,
+				SplitCoefficient: price.SplitCoefficient,
+				Factor:           factor,
+			}
+		}
+
+		prevClose = price.Close.Float64()
+	}
+
+	return raw, actions, nil
+}
+
+// Adjust returns the prices, latest first, adjusted for the corporate actions taking effect after each day. Every
+// action after the earliest price must be given. Volumes are left as traded.
+func Adjust(prices []*DailyPrice, actions CorporateActions) []*DailyPrice {
+	days := make([]string, 0, len(actions))
+	for day := range actions {
+		days = append(days, day)
+	}
+
+	// latest first to match the prices
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+
+	adjusted := make([]*DailyPrice, 0, len(prices))
+
+	factor := 1.0
+	next := 0
+
+	for _, price := range prices {
+		for ; next < len(days) && days[next] > price.Day; next++ {
+			factor *= actions[days[next]].Factor
+		}
+
+		if factor == 1 {
+			adjusted = append(adjusted, price)
+
+			continue
+		}
+
+		p := *price.Price
+
+		for _, field := range []*Decimal{&p.Open, &p.High, &p.Low, &p.Close} {
+			*field = field.MulFloat(factor, _adjustedScale)
+		}
+
+		adjusted = append(adjusted, &DailyPrice{
+			Day:   price.Day,
+			Price: &p,
+		})
+	}
+
+	return adjusted
+}
+
+// AverageClose returns the average closing price of the prices rounded to 2 decimal places
+func AverageClose(prices []*DailyPrice) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+
+	var totClose Decimal
+
+	for _, price := range prices {
+		totClose = totClose.Add(price.Price.Close)
+	}
+
+	return totClose.Div(int64(len(prices)), 2).Float64()
+}
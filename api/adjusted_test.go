@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustedResponse_Split(t *testing.T) {
+	res := &AdjustedResponse{
+		MetaData: MD{Symbol: "AAPL", LastRefreshed: "2020-08-31"},
+		DailyPrices: TimeSeriesDailyAdjusted{
+			"2020-08-31": {Open: MustParseDecimal("127.5800"), High: MustParseDecimal("131.0000"), Low: MustParseDecimal("126.0000"), Close: MustParseDecimal("129.0400"), AdjustedClose: MustParseDecimal("129.0400"), Volume: 225702700, DividendAmount: MustParseDecimal("0.0000"), SplitCoefficient: MustParseDecimal("4.0")},
+			"2020-08-28": {Open: MustParseDecimal("504.0500"), High: MustParseDecimal("505.7700"), Low: MustParseDecimal("498.3100"), Close: MustParseDecimal("499.2300"), AdjustedClose: MustParseDecimal("124.8075"), Volume: 46907479, DividendAmount: MustParseDecimal("0.0000"), SplitCoefficient: MustParseDecimal("1.0")},
+			"2020-08-07": {Open: MustParseDecimal("452.8200"), High: MustParseDecimal("454.7000"), Low: MustParseDecimal("441.1700"), Close: MustParseDecimal("444.4500"), AdjustedClose: MustParseDecimal("111.1125"), Volume: 49511403, DividendAmount: MustParseDecimal("0.8200"), SplitCoefficient: MustParseDecimal("1.0")},
+			"2020-08-06": {Open: MustParseDecimal("441.6200"), High: MustParseDecimal("457.6500"), Low: MustParseDecimal("439.1900"), Close: MustParseDecimal("455.6100"), AdjustedClose: MustParseDecimal("113.7030"), Volume: 62644595, DividendAmount: MustParseDecimal("0.0000"), SplitCoefficient: MustParseDecimal("1.0")},
+		},
+	}
+
+	raw, actions, err := res.Split()
+	assert.NoError(t, err)
+
+	assert.Equal(t, res.MetaData, raw.MetaData)
+	assert.Equal(t, Price{Open: MustParseDecimal("504.0500"), High: MustParseDecimal("505.7700"), Low: MustParseDecimal("498.3100"), Close: MustParseDecimal("499.2300"), Volume: 46907479}, raw.DailyPrices["2020-08-28"])
+	assert.Len(t, raw.DailyPrices, 4)
+
+	assert.Equal(t, CorporateActions{
+		"2020-08-31": {DividendAmount: MustParseDecimal("0.0000"), SplitCoefficient: MustParseDecimal("4.0"), Factor: 0.25},
+		"2020-08-07": {DividendAmount: MustParseDecimal("0.8200"), SplitCoefficient: MustParseDecimal("1.0"), Factor: 1 - 0.82/455.61},
+	}, actions)
+
+	// a compact series starting on the dividend has no close before it to factor the dividend with
+	delete(res.DailyPrices, "2020-08-06")
+
+	_, actions, err = res.Split()
+	assert.NoError(t, err)
+
+	assert.Equal(t, CorporateActions{
+		"2020-08-31": {DividendAmount: MustParseDecimal("0.0000"), SplitCoefficient: MustParseDecimal("4.0"), Factor: 0.25},
+	}, actions)
+}
+
+func TestAdjust(t *testing.T) {
+	prices := []*DailyPrice{
+		{Day: "2020-08-31", Price: &Price{Open: MustParseDecimal("127.5800"), High: MustParseDecimal("131.0000"), Low: MustParseDecimal("126.0000"), Close: MustParseDecimal("129.0400"), Volume: 225702700}},
+		{Day: "2020-08-28", Price: &Price{Open: MustParseDecimal("504.0500"), High: MustParseDecimal("505.7700"), Low: MustParseDecimal("498.3100"), Close: MustParseDecimal("499.2300"), Volume: 46907479}},
+		{Day: "2020-08-06", Price: &Price{Open: MustParseDecimal("441.6200"), High: MustParseDecimal("457.6500"), Low: MustParseDecimal("439.1900"), Close: MustParseDecimal("455.6100"), Volume: 62644595}},
+	}
+
+	actions := CorporateActions{
+		"2020-08-31": {SplitCoefficient: MustParseDecimal("4.0"), Factor: 0.25},
+		"2020-08-07": {DividendAmount: MustParseDecimal("0.8200"), Factor: 1 - 0.82/455.61},
+	}
+
+	adjusted := Adjust(prices, actions)
+
+	assert.Equal(t, []*DailyPrice{
+		// nothing after the latest day so it is left as is
+		prices[0],
+		{Day: "2020-08-28", Price: &Price{Open: MustParseDecimal("126.0125"), High: MustParseDecimal("126.4425"), Low: MustParseDecimal("124.5775"), Close: MustParseDecimal("124.8075"), Volume: 46907479}},
+		// the close matches the adjusted close reported by the api
+		{Day: "2020-08-06", Price: &Price{Open: MustParseDecimal("110.2063"), High: MustParseDecimal("114.2066"), Low: MustParseDecimal("109.5999"), Close: MustParseDecimal("113.6975"), Volume: 62644595}},
+	}, adjusted)
+
+	assert.Equal(t, 122.52, AverageClose(adjusted))
+}
@@ -3,13 +3,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
 	"net/http"
+	"net/url"
 	"sort"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -20,10 +22,35 @@ const (
 	Format = "2006-01-02"
 )
 
+// OutputSize is the number of days of prices returned by the api
+type OutputSize string
+
+const (
+	// Compact returns the latest 100 days of prices
+	Compact OutputSize = "compact"
+	// Full returns the full-length time series of 20+ years of prices
+	Full OutputSize = "full"
+)
+
+// Provider is a source of daily prices for symbols
+type Provider interface {
+	// Name identifies the provider in configuration and responses
+	Name() string
+	// Daily gets the daily series of symbol, compact for about the latest 100 days or full for the whole history
+	Daily(ctx context.Context, symbol string, size OutputSize) (*Series, error)
+}
+
 // API is an interface to be implemented by the client that connects to it to interact with stock prices API
 type API interface {
-	GetPrices(ctx context.Context) (*OrderedResponse, error)
-	GetAllPrices(ctx context.Context) (*JSONResponse, error)
+	Provider
+	GetPrices(ctx context.Context, symbol string, days int) (*OrderedResponse, error)
+	GetAllPrices(ctx context.Context, symbol string) (*JSONResponse, error)
+	GetSeries(ctx context.Context, symbol string, size OutputSize) (*JSONResponse, error)
+	GetAdjustedSeries(ctx context.Context, symbol string, size OutputSize) (*AdjustedResponse, error)
+	GetIntraday(ctx context.Context, symbol string, interval Interval, size OutputSize) (*IntradayResponse, error)
+	GetPeriodSeries(ctx context.Context, symbol string, period Period) (*JSONResponse, error)
+	Quota() *Quota
+	Health() Health
 }
 
 // Option specifies a builder function for configuring a API's client
@@ -32,6 +59,21 @@ type Option func(API)
 type Client struct {
 	options    options
 	httpClient *retryablehttp.Client
+
+	mu     sync.Mutex
+	health Health
+}
+
+// Health is whether the api could be reached on the latest requests
+type Health struct {
+	LastSuccess time.Time `json:"last_success"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at"`
+}
+
+// Reachable reports whether the latest request reached the api, true before any request is made
+func (h Health) Reachable() bool {
+	return !h.LastErrorAt.After(h.LastSuccess)
 }
 
 // this is a check to confirm the implementation is compatible with dependent interfaces
@@ -48,10 +90,16 @@ func New(opts ...Option) API {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = client.options.maxRetries
 	retryClient.HTTPClient.Timeout = client.options.timeout
+
+	if client.options.transport != nil {
+		retryClient.HTTPClient.Transport = client.options.transport
+	}
+
 	retryClient.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
 		// too many requests
+		// the daily quota is managed by Quota, a 429 from the api means the minute window is used
 		if resp != nil {
-			if resp.StatusCode == http.StatusTooManyRequests { // TODO differentiate between this and total number of requests a day
+			if resp.StatusCode == http.StatusTooManyRequests {
 				return 1 * time.Minute // 5 request quota per minute
 			}
 		}
@@ -66,6 +114,25 @@ func New(opts ...Option) API {
 		return sleep
 	}
 
+	// hand back the last response once retries are exhausted so a 429 can be reported as such
+	retryClient.ErrorHandler = retryablehttp.PassthroughErrorHandler
+
+	if client.options.quota != nil {
+		retryClient.HTTPClient.Transport = &quotaTransport{
+			quota: client.options.quota,
+			next:  retryClient.HTTPClient.Transport,
+		}
+
+		// retrying will not free up the quota
+		retryClient.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if isQuotaError(err) {
+				return false, err
+			}
+
+			return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+		}
+	}
+
 	client.httpClient = retryClient
 
 	return client
@@ -82,21 +149,64 @@ func (c *Client) performRequest(ctx context.Context, requestURL string) (io.Read
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error performing list stock prices request: %s", err)
+		// the quota rejected the request before it was sent or the caller went away, neither says the api is down
+		if !isQuotaError(err) && !errors.Is(err, context.Canceled) {
+			c.recordFailure(err)
+		}
+
+		return nil, fmt.Errorf("error performing list stock prices request: %w", requestError(ctx, err))
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusTooManyRequests {
-		return nil, fmt.Errorf("unexpected response status code: %d", resp.StatusCode)
+	c.recordSuccess()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		_ = resp.Body.Close()
+
+		return nil, &UpstreamError{Err: ErrRateLimited, Message: "too many requests"}
+	default:
+		_ = resp.Body.Close()
+
+		return nil, &UpstreamError{Err: ErrUpstream, Message: fmt.Sprintf("unexpected response status code: %d", resp.StatusCode)}
 	}
 
 	return resp.Body, nil
 }
 
-// GetPrices gets the stock prices for the last NDAYS
+// Quota returns the quota requests are scheduled within, nil when the client does not manage a quota
+func (c *Client) Quota() *Quota {
+	return c.options.quota
+}
+
+// Health returns whether the api could be reached on the latest requests
+func (c *Client) Health() Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.health
+}
+
+func (c *Client) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.health.LastSuccess = time.Now()
+}
+
+func (c *Client) recordFailure(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.health.LastError = err.Error()
+	c.health.LastErrorAt = time.Now()
+}
+
+// GetPrices gets the stock prices of symbol for the last days
 // By default, outputsize=compact. The "compact" option is recommended
 // if you would like to reduce the data size of each API call as it retrieves 100 days of data
-func (c *Client) GetPrices(ctx context.Context) (*OrderedResponse, error) {
-	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_DAILY&symbol=%s", c.options.baseURL, c.options.apiKey, c.options.symbol)
+func (c *Client) GetPrices(ctx context.Context, symbol string, days int) (*OrderedResponse, error) {
+	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_DAILY&symbol=%s", c.options.baseURL, c.options.apiKey, url.QueryEscape(symbol))
 
 	respBody, err := c.performRequest(ctx, requestURL)
 	if err != nil {
@@ -107,7 +217,7 @@ func (c *Client) GetPrices(ctx context.Context) (*OrderedResponse, error) {
 		_ = respBody.Close()
 	}()
 
-	prices, avgClose, err := sanitize(respBody, c.options.nDays)
+	prices, avgClose, err := sanitize(respBody, days)
 	if err != nil {
 		return nil, err
 	}
@@ -119,9 +229,14 @@ func (c *Client) GetPrices(ctx context.Context) (*OrderedResponse, error) {
 
 }
 
-// GetAllPrices gets the stock prices for the full-length time series of 20+ years of stock prices
-func (c *Client) GetAllPrices(ctx context.Context) (*JSONResponse, error) {
-	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_DAILY&symbol=%s&datatype=json&outputsize=full", c.options.baseURL, c.options.apiKey, c.options.symbol)
+// GetAllPrices gets the stock prices of symbol for the full-length time series of 20+ years of stock prices
+func (c *Client) GetAllPrices(ctx context.Context, symbol string) (*JSONResponse, error) {
+	return c.GetSeries(ctx, symbol, Full)
+}
+
+// GetSeries gets the raw daily time series of symbol, compact for the latest 100 days or full for 20+ years
+func (c *Client) GetSeries(ctx context.Context, symbol string, size OutputSize) (*JSONResponse, error) {
+	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_DAILY&symbol=%s&datatype=json&outputsize=%s", c.options.baseURL, c.options.apiKey, url.QueryEscape(symbol), size)
 
 	respBody, err := c.performRequest(ctx, requestURL)
 	if err != nil {
@@ -132,13 +247,24 @@ func (c *Client) GetAllPrices(ctx context.Context) (*JSONResponse, error) {
 		_ = respBody.Close()
 	}()
 
-	body, err := ioutil.ReadAll(respBody)
+	return decode(respBody)
+}
+
+// decode reads the api response and returns any error it reports
+func decode(bodyReader io.Reader) (*JSONResponse, error) {
+	body, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
 
 	var res JSONResponse
 
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		log.Error().Err(err).Msg("reading response")
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if err = checkResponse(&res.Errors); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
@@ -149,13 +275,9 @@ func sanitize(bodyReader io.ReadCloser, nDays int) ([]*DailyPrice, float64, erro
 	// create the response to return
 	nDaysData := make([]*DailyPrice, 0)
 
-	body, err := ioutil.ReadAll(bodyReader)
-
-	var res JSONResponse
-
-	err = json.Unmarshal(body, &res)
+	res, err := decode(bodyReader)
 	if err != nil {
-		log.Error().Err(err).Msg("reading response")
+		return nil, 0, err
 	}
 
 	// need to get the contents of NDays and we know its a key value pair in the response
@@ -176,7 +298,7 @@ func sanitize(bodyReader io.ReadCloser, nDays int) ([]*DailyPrice, float64, erro
 	})
 
 	var counter int
-	var totClose float64
+	var totClose Decimal
 
 	for i := len(days) - 1; i >= 0; i-- {
 		if counter == nDays {
@@ -190,14 +312,7 @@ func sanitize(bodyReader io.ReadCloser, nDays int) ([]*DailyPrice, float64, erro
 			continue
 		}
 
-		closePrice, err := strconv.ParseFloat(price.Close, 64)
-		if err != nil {
-			log.Error().Err(err).Msg("converting close price:")
-
-			continue
-		}
-
-		totClose += closePrice
+		totClose = totClose.Add(price.Close)
 
 		nDaysData = append(nDaysData, &DailyPrice{
 			Day:   days[i].Format(Format),
@@ -207,8 +322,8 @@ func sanitize(bodyReader io.ReadCloser, nDays int) ([]*DailyPrice, float64, erro
 		counter++
 	}
 
-	// average close price rounded to 2 decimal places
-	avgClose := FixedPrecision(totClose/float64(nDays), 2)
+	// average close price over the days found rounded to 2 decimal places, zero when none were
+	avgClose := totClose.Div(int64(counter), 2).Float64()
 
 	return nDaysData, avgClose, nil
 }
@@ -1,10 +1,15 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -93,36 +98,52 @@ func Test_sanitize(t *testing.T) {
 				{
 					Day: "2022-04-01",
 					Price: &Price{
-						Open:   "129.6600",
-						High:   "130.2700",
-						Low:    "128.0600",
-						Close:  "130.1500",
-						Volume: "4012373",
+						Open:   MustParseDecimal("129.6600"),
+						High:   MustParseDecimal("130.2700"),
+						Low:    MustParseDecimal("128.0600"),
+						Close:  MustParseDecimal("130.1500"),
+						Volume: 4012373,
 					},
 				},
 				{
 					Day: "2022-03-31",
 					Price: &Price{
-						Open:   "130.7200",
-						High:   "131.8800",
-						Low:    "130.0000",
-						Close:  "130.0200",
-						Volume: "4274029",
+						Open:   MustParseDecimal("130.7200"),
+						High:   MustParseDecimal("131.8800"),
+						Low:    MustParseDecimal("130.0000"),
+						Close:  MustParseDecimal("130.0200"),
+						Volume: 4274029,
 					},
 				},
 				{
 					Day: "2022-03-30",
 					Price: &Price{
-						Open:   "132.0100",
-						High:   "133.0800",
-						Low:    "131.3900",
-						Close:  "132.1300",
-						Volume: "2622860",
+						Open:   MustParseDecimal("132.0100"),
+						High:   MustParseDecimal("133.0800"),
+						Low:    MustParseDecimal("131.3900"),
+						Close:  MustParseDecimal("132.1300"),
+						Volume: 2622860,
 					},
 				},
 			},
 			avgClose: 130.77,
 		},
+		{
+			name: "no days",
+			bodyReader: ioutil.NopCloser(strings.NewReader(`{
+    "Time Series (Daily)": {
+        "2022-04-01": {
+            "1. open": "129.6600",
+            "2. high": "130.2700",
+            "3. low": "128.0600",
+            "4. close": "130.1500",
+            "5. volume": "4012373"
+        }
+    }
+}`)),
+			nDays:  0,
+			prices: []*DailyPrice{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -138,3 +159,134 @@ func Test_sanitize(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_GetPrices_upstreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		err    error
+	}{
+		{
+			name:   "per minute throttling note",
+			status: http.StatusOK,
+			body:   `{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute and 500 calls per day. Please visit https://www.alphavantage.co/premium/ if you would like to target a higher API call frequency."}`,
+			err:    ErrRateLimited,
+		},
+		{
+			name:   "daily quota information",
+			status: http.StatusOK,
+			body:   `{"Information": "Thank you for using Alpha Vantage! Our standard API rate limit is 500 requests per day. Please subscribe to any of the premium plans at https://www.alphavantage.co/premium/ to instantly remove all daily rate limits."}`,
+			err:    ErrQuotaExceeded,
+		},
+		{
+			name:   "invalid symbol",
+			status: http.StatusOK,
+			body:   `{"Error Message": "Invalid API call. Please retry or visit the documentation (https://www.alphavantage.co/documentation/) for TIME_SERIES_DAILY."}`,
+			err:    ErrInvalidSymbol,
+		},
+		{
+			name:   "invalid api key",
+			status: http.StatusOK,
+			body:   `{"Error Message": "the parameter apikey is invalid or missing. Please claim your free API key on (https://www.alphavantage.co/support/#api-key). It should take less than 20 seconds."}`,
+			err:    ErrInvalidAPIKey,
+		},
+		{
+			name:   "too many requests status",
+			status: http.StatusTooManyRequests,
+			body:   ``,
+			err:    ErrRateLimited,
+		},
+		{
+			name:   "server error status",
+			status: http.StatusBadGateway,
+			body:   ``,
+			err:    ErrUpstream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(time.Second))
+
+			_, err := client.GetPrices(context.Background(), "IBM", 3)
+			assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+
+			_, err = client.GetAllPrices(context.Background(), "IBM")
+			assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+		})
+	}
+}
+
+func TestClient_GetSeries_deadlines(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+		err     error
+		// failure is whether the api is reported as failing
+		failure bool
+	}{
+		{
+			name:    "the api not answering within the client timeout",
+			timeout: 50 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			err:     ErrTimeout,
+			failure: true,
+		},
+		{
+			name:    "the api not answering within the request deadline",
+			timeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			err:     context.DeadlineExceeded,
+			failure: true,
+		},
+		{
+			name:    "the caller going away",
+			timeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+
+				return ctx, cancel
+			},
+			err: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			}))
+			defer srv.Close()
+			defer close(release)
+
+			client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(tt.timeout))
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+
+			_, err := client.GetSeries(ctx, "IBM", Compact)
+			assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+			assert.Less(t, int64(time.Since(start)), int64(time.Second), "the request outlived its deadline")
+
+			assert.Equal(t, tt.failure, client.Health().LastError != "")
+		})
+	}
+}
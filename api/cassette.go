@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CassetteMode is whether a cassette records or replays interactions
+type CassetteMode string
+
+const (
+	// Record sends requests upstream and records them along with their response
+	Record CassetteMode = "record"
+	// Replay answers requests with the recorded responses without sending them
+	Replay CassetteMode = "replay"
+
+	_redacted = "REDACTED"
+)
+
+// ErrNotRecorded is returned when replaying a request the cassette has no response for
+var ErrNotRecorded = errors.New("request not recorded")
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is what is kept of a request to match it on replay
+type RecordedRequest struct {
+	Method string `json:"method"`
+	// URL has its apikey redacted
+	URL string `json:"url"`
+}
+
+// RecordedResponse is the response a request got
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// cassetteFile is the layout of a cassette on disk
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Cassette is an http.RoundTripper that records the interactions with the api to a file so they can be replayed
+// offline. Requests are matched on their method, path and query, the apikey being left out, and a request made
+// several times gets the responses recorded for it in order, the last one being repeated once they run out.
+type Cassette struct {
+	path string
+	mode CassetteMode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	played       map[string]int
+}
+
+// NewCassette returns a cassette recording to path the requests sent with next, or replaying those recorded in path.
+// Recording starts a new cassette.
+func NewCassette(path string, mode CassetteMode, next http.RoundTripper) (*Cassette, error) {
+	c := &Cassette{
+		path:   path,
+		mode:   mode,
+		next:   next,
+		played: make(map[string]int),
+	}
+
+	switch mode {
+	case Record:
+		if c.next == nil {
+			c.next = http.DefaultTransport
+		}
+	case Replay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cassette: %w", err)
+		}
+
+		var file cassetteFile
+		if err = json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("decoding cassette: %w", err)
+		}
+
+		c.interactions = file.Interactions
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q: expected record or replay", mode)
+	}
+
+	return c, nil
+}
+
+// RoundTrip records or replays the request
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.mode == Replay {
+		return c.replay(req)
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("recording response: %w", err)
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := make(http.Header)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redact(req.URL).String(),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       string(body),
+		},
+	})
+
+	if err = c.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + match(req.URL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []Interaction
+
+	for _, interaction := range c.interactions {
+		recorded, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			continue
+		}
+
+		if interaction.Request.Method+" "+match(recorded) == key {
+			matches = append(matches, interaction)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, redact(req.URL))
+	}
+
+	played := c.played[key]
+	if played >= len(matches) {
+		played = len(matches) - 1
+	}
+
+	c.played[key]++
+
+	recorded := matches[played].Response
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// save writes the cassette through a temporary file so an interrupted write does not leave it truncated
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	return nil
+}
+
+// redact returns the url with its apikey replaced
+func redact(u *url.URL) *url.URL {
+	redacted := *u
+
+	query := redacted.Query()
+	if query.Get("apikey") != "" {
+		query.Set("apikey", _redacted)
+	}
+
+	redacted.RawQuery = query.Encode()
+
+	return &redacted
+}
+
+// match returns what a request is matched on, its path and query without the apikey. The query is encoded sorted by
+// key so the order of the parameters does not matter.
+func match(u *url.URL) string {
+	query := u.Query()
+	query.Del("apikey")
+
+	return strings.TrimSuffix(u.Path, "/") + "?" + query.Encode()
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	bodies := []string{
+		`{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute and 500 calls per day."}`,
+		`{"Meta Data": {"2. Symbol": "IBM", "3. Last Refreshed": "2022-04-01"}, "Time Series (Daily)": {"2022-04-01": {"1. open": "129.6600", "2. high": "130.2700", "3. low": "128.0600", "4. close": "130.1500", "5. volume": "4012373"}}}`,
+	}
+
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(bodies[requests%len(bodies)]))
+		requests++
+	}))
+
+	recorder, err := NewCassette(path, Record, nil)
+	assert.NoError(t, err)
+
+	client := New(WithBaseURL(srv.URL), WithKey("secret-key"), WithMaxRetries(0), WithTimeout(time.Second), WithTransport(recorder))
+
+	_, err = client.GetSeries(context.Background(), "IBM", Compact)
+	assert.True(t, errors.Is(err, ErrRateLimited), "expected %v got %v", ErrRateLimited, err)
+
+	recorded, err := client.GetSeries(context.Background(), "IBM", Compact)
+	assert.NoError(t, err)
+
+	srv.Close()
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-key")
+	assert.Contains(t, string(data), "apikey=REDACTED")
+
+	player, err := NewCassette(path, Replay, nil)
+	assert.NoError(t, err)
+
+	// replayed with another key and base url, the requests never leave the client
+	client = New(WithBaseURL("http://upstream.invalid"), WithKey("other-key"), WithMaxRetries(0), WithTransport(player))
+
+	_, err = client.GetSeries(context.Background(), "IBM", Compact)
+	assert.True(t, errors.Is(err, ErrRateLimited), "expected %v got %v", ErrRateLimited, err)
+
+	replayed, err := client.GetSeries(context.Background(), "IBM", Compact)
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+
+	// the last response is repeated once the recorded ones run out
+	replayed, err = client.GetSeries(context.Background(), "IBM", Compact)
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+
+	_, err = client.GetSeries(context.Background(), "MSFT", Compact)
+	assert.True(t, errors.Is(err, ErrNotRecorded), "expected %v got %v", ErrNotRecorded, err)
+	assert.Contains(t, err.Error(), "apikey=REDACTED")
+
+	assert.Equal(t, 2, requests)
+}
+
+func TestNewCassette_errors(t *testing.T) {
+	_, err := NewCassette(filepath.Join(t.TempDir(), "missing.json"), Replay, nil)
+	assert.Error(t, err)
+
+	_, err = NewCassette("cassette.json", "rewind", nil)
+	assert.EqualError(t, err, `invalid cassette mode "rewind": expected record or replay`)
+}
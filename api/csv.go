@@ -0,0 +1,248 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const (
+	// CSV is the name of the CSV provider
+	CSV = "csv"
+	// DefaultCSVBaseURL serves daily prices of US listed symbols as CSV
+	DefaultCSVBaseURL = "https://stooq.com/q/d/l/"
+	// _compactDays is the number of days in a compact series, as the Alpha Vantage compact output size
+	_compactDays = 100
+)
+
+// CSVOption specifies a builder function for configuring a CSVClient
+type CSVOption func(*CSVClient)
+
+// CSVClient gets daily prices from a source serving them as CSV with a Date, Open, High, Low, Close and optional
+// Volume column, in the format of stooq.com e.g.
+//
+//	Date,Open,High,Low,Close,Volume
+//	2022-03-31,131.4,131.82,129.89,130.02,4365441
+//
+// The source needs no api key so it can stand in for symbols Alpha Vantage does not cover, or when its quota runs out.
+type CSVClient struct {
+	baseURL    string
+	suffix     string
+	timeout    time.Duration
+	maxRetries int
+	httpClient *retryablehttp.Client
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Provider = (*CSVClient)(nil)
+
+// NewCSV initializes the CSV provider's client
+func NewCSV(opts ...CSVOption) *CSVClient {
+	client := &CSVClient{
+		baseURL: DefaultCSVBaseURL,
+		suffix:  ".us",
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	retryClient := retryablehttp.NewClient()
+	retryClient.RetryMax = client.maxRetries
+	retryClient.HTTPClient.Timeout = client.timeout
+	retryClient.Logger = nil
+	retryClient.ErrorHandler = retryablehttp.PassthroughErrorHandler
+
+	client.httpClient = retryClient
+
+	return client
+}
+
+// WithCSVBaseURL sets base URL path for requests
+func WithCSVBaseURL(url string) CSVOption {
+	return func(c *CSVClient) {
+		c.baseURL = url
+	}
+}
+
+// WithCSVSymbolSuffix sets the suffix appended to symbols to name them to the source, ".us" by default
+func WithCSVSymbolSuffix(suffix string) CSVOption {
+	return func(c *CSVClient) {
+		c.suffix = suffix
+	}
+}
+
+// WithCSVTimeout sets a timeout for the http client
+func WithCSVTimeout(to time.Duration) CSVOption {
+	return func(c *CSVClient) {
+		c.timeout = to
+	}
+}
+
+// WithCSVMaxRetries sets times http client will retry the request
+func WithCSVMaxRetries(retries int) CSVOption {
+	return func(c *CSVClient) {
+		c.maxRetries = retries
+	}
+}
+
+// Name returns the name of the CSV provider
+func (c *CSVClient) Name() string {
+	return CSV
+}
+
+// Daily gets the daily series of symbol, the source always returns the full history so a compact series is cut to
+// the latest 100 days
+func (c *CSVClient) Daily(ctx context.Context, symbol string, size OutputSize) (*Series, error) {
+	requestURL := fmt.Sprintf("%s?s=%s&i=d", c.baseURL, url.QueryEscape(strings.ToLower(symbol)+c.suffix))
+
+	req, err := retryablehttp.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating csv prices request: %w", err)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error performing csv prices request: %w", requestError(ctx, err))
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return nil, &UpstreamError{Err: ErrRateLimited, Message: "too many requests"}
+	default:
+		return nil, &UpstreamError{Err: ErrUpstream, Message: fmt.Sprintf("unexpected response status code: %d", resp.StatusCode)}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	prices, err := parseCSV(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if size != Full && len(prices) > _compactDays {
+		prices = prices[:_compactDays]
+	}
+
+	series := &Series{
+		Symbol: strings.ToUpper(symbol),
+		Source: CSV,
+		Prices: prices,
+	}
+
+	if len(prices) != 0 {
+		series.LastRefreshed = prices[0].Day
+	}
+
+	return series, nil
+}
+
+// parseCSV parses the prices of a CSV body, latest first. Columns are found by their header so their order does not
+// matter, a missing volume column is read as no volume.
+func parseCSV(body []byte) ([]*DailyPrice, error) {
+	trimmed := strings.TrimSpace(string(body))
+
+	// errors are reported as a plain text body
+	switch lower := strings.ToLower(trimmed); {
+	case lower == "no data" || lower == "":
+		return nil, &UpstreamError{Err: ErrInvalidSymbol, Message: "no data"}
+	case strings.Contains(lower, "limit"):
+		return nil, &UpstreamError{Err: ErrQuotaExceeded, Message: trimmed}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"date", "open", "high", "low", "close"} {
+		if _, ok := columns[name]; !ok {
+			return nil, &UpstreamError{Err: ErrUpstream, Message: fmt.Sprintf("missing %s column", name)}
+		}
+	}
+
+	var prices []*DailyPrice
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+
+		price, err := parseRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+
+		prices = append(prices, price)
+	}
+
+	// the source sorts chronologically
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Day > prices[j].Day
+	})
+
+	return prices, nil
+}
+
+func parseRecord(record []string, columns map[string]int) (*DailyPrice, error) {
+	day := record[columns["date"]]
+	if _, err := time.Parse(Format, day); err != nil {
+		return nil, fmt.Errorf("invalid date %q", day)
+	}
+
+	var price Price
+
+	for name, field := range map[string]*Decimal{"open": &price.Open, "high": &price.High, "low": &price.Low, "close": &price.Close} {
+		d, err := ParseDecimal(record[columns[name]])
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", name, day, err)
+		}
+
+		*field = d
+	}
+
+	if i, ok := columns["volume"]; ok && record[i] != "" {
+		volume, err := strconv.ParseFloat(record[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid volume of %s: %q", day, record[i])
+		}
+
+		price.Volume = int64(volume)
+	}
+
+	return &DailyPrice{Day: day, Price: &price}, nil
+}
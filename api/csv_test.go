@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVClient_Daily(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		size     OutputSize
+		expected *Series
+		err      error
+	}{
+		{
+			name:   "parses and orders latest first",
+			status: http.StatusOK,
+			body: "Date,Open,High,Low,Close,Volume\n" +
+				"2022-03-30,131.7,132.2,130.9,131.89,4253600\n" +
+				"2022-03-31,131.4,131.82,129.89,130.02,4365441\n",
+			size: Compact,
+			expected: &Series{
+				Symbol:        "IBM",
+				Source:        CSV,
+				LastRefreshed: "2022-03-31",
+				Prices: []*DailyPrice{
+					{Day: "2022-03-31", Price: &Price{Open: MustParseDecimal("131.4"), High: MustParseDecimal("131.82"), Low: MustParseDecimal("129.89"), Close: MustParseDecimal("130.02"), Volume: 4365441}},
+					{Day: "2022-03-30", Price: &Price{Open: MustParseDecimal("131.7"), High: MustParseDecimal("132.2"), Low: MustParseDecimal("130.9"), Close: MustParseDecimal("131.89"), Volume: 4253600}},
+				},
+			},
+		},
+		{
+			name:   "finds columns by header and reads a missing volume as none",
+			status: http.StatusOK,
+			body:   "date,close,low,high,open\n2022-03-31,130.02,129.89,131.82,131.4\n",
+			size:   Full,
+			expected: &Series{
+				Symbol:        "IBM",
+				Source:        CSV,
+				LastRefreshed: "2022-03-31",
+				Prices: []*DailyPrice{
+					{Day: "2022-03-31", Price: &Price{Open: MustParseDecimal("131.4"), High: MustParseDecimal("131.82"), Low: MustParseDecimal("129.89"), Close: MustParseDecimal("130.02")}},
+				},
+			},
+		},
+		{
+			name:   "unknown symbol",
+			status: http.StatusOK,
+			body:   "No data",
+			err:    ErrInvalidSymbol,
+		},
+		{
+			name:   "daily limit",
+			status: http.StatusOK,
+			body:   "Exceeded the daily hits limit",
+			err:    ErrQuotaExceeded,
+		},
+		{
+			name:   "missing column",
+			status: http.StatusOK,
+			body:   "Date,Open,High,Low\n2022-03-31,131.4,131.82,129.89\n",
+			err:    ErrUpstream,
+		},
+		{
+			name:   "too many requests status",
+			status: http.StatusTooManyRequests,
+			err:    ErrRateLimited,
+		},
+		{
+			name:   "server error status",
+			status: http.StatusBadGateway,
+			err:    ErrUpstream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "ibm.us", r.URL.Query().Get("s"))
+				assert.Equal(t, "d", r.URL.Query().Get("i"))
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := NewCSV(WithCSVBaseURL(srv.URL), WithCSVMaxRetries(0), WithCSVTimeout(time.Second))
+
+			series, err := client.Daily(context.Background(), "IBM", tt.size)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, series)
+		})
+	}
+}
+
+func TestCSVClient_Daily_compact(t *testing.T) {
+	var body strings.Builder
+
+	body.WriteString("Date,Open,High,Low,Close,Volume\n")
+
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		body.WriteString(day.AddDate(0, 0, i).Format(Format) + ",1,1,1," + strconv.Itoa(i) + ",100\n")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer srv.Close()
+
+	client := NewCSV(WithCSVBaseURL(srv.URL), WithCSVMaxRetries(0))
+
+	series, err := client.Daily(context.Background(), "IBM", Compact)
+	assert.NoError(t, err)
+	assert.Len(t, series.Prices, 100)
+	assert.Equal(t, day.AddDate(0, 0, 149).Format(Format), series.Prices[0].Day)
+
+	series, err = client.Daily(context.Background(), "IBM", Full)
+	assert.NoError(t, err)
+	assert.Len(t, series.Prices, 150)
+}
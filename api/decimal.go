@@ -0,0 +1,279 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// _maxScale bounds the decimal places of a Decimal so scaling stays within int64
+const _maxScale = 12
+
+var pow10 = func() [_maxScale + 1]int64 {
+	var p [_maxScale + 1]int64
+
+	p[0] = 1
+	for i := 1; i <= _maxScale; i++ {
+		p[i] = p[i-1] * 10
+	}
+
+	return p
+}()
+
+// Decimal is a fixed point decimal number, an integer value scaled by 10^-scale. Prices are parsed into a Decimal
+// once when they are received and keep the number of decimal places they were quoted with, 309.3700 stays 309.3700.
+// It is encoded in JSON as a string, the way the api quotes prices.
+type Decimal struct {
+	value int64
+	scale int
+}
+
+// NewDecimal returns the decimal value * 10^-scale, a scale outside of 0 to 12 being rounded or padded into it
+func NewDecimal(value int64, scale int) Decimal {
+	for ; scale > _maxScale; scale-- {
+		value = divRound(value, 10)
+	}
+
+	for ; scale < 0; scale++ {
+		value *= 10
+	}
+
+	return Decimal{value: value, scale: scale}
+}
+
+// ParseDecimal parses a decimal number such as 309.3700 or -0.5, exponents are not accepted
+func ParseDecimal(s string) (Decimal, error) {
+	digits := s
+	negative := false
+
+	switch {
+	case strings.HasPrefix(digits, "-"):
+		negative = true
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	}
+
+	whole, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+
+	if whole == "" && fraction == "" || len(fraction) > _maxScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	var value int64
+
+	for _, c := range whole + fraction {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+
+		if value > (math.MaxInt64-int64(c-'0'))/10 {
+			return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+		}
+
+		value = value*10 + int64(c-'0')
+	}
+
+	if negative {
+		value = -value
+	}
+
+	return Decimal{value: value, scale: len(fraction)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a decimal, it simplifies declaring constant prices
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// String formats the decimal with its scale
+func (d Decimal) String() string {
+	if d.scale == 0 {
+		return strconv.FormatInt(d.value, 10)
+	}
+
+	value := d.value
+	sign := ""
+
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	digits := strconv.FormatInt(value, 10)
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+}
+
+// Float64 returns the nearest float64 to the decimal
+func (d Decimal) Float64() float64 {
+	return float64(d.value) / float64(pow10[d.scale])
+}
+
+// Scale returns the number of decimal places of the decimal
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// IsZero reports whether the decimal is zero at any scale
+func (d Decimal) IsZero() bool {
+	return d.value == 0
+}
+
+// Cmp compares the decimals regardless of their scale, returning -1, 0 or +1
+func (d Decimal) Cmp(o Decimal) int {
+	a, b := align(d, o)
+
+	switch {
+	case a.value < b.value:
+		return -1
+	case a.value > b.value:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Add returns d + o at the larger scale of the two
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b := align(d, o)
+
+	return Decimal{value: a.value + b.value, scale: a.scale}
+}
+
+// Sub returns d - o at the larger scale of the two
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b := align(d, o)
+
+	return Decimal{value: a.value - b.value, scale: a.scale}
+}
+
+// Div returns d / n rounded half away from zero to scale decimal places, zero when n is zero. The scale is bounded
+// to 0 to 12 decimal places.
+func (d Decimal) Div(n int64, scale int) Decimal {
+	scale = boundScale(scale)
+
+	if n == 0 {
+		return Decimal{scale: scale}
+	}
+
+	num, den := d.value, n
+	if scale >= d.scale {
+		num *= pow10[scale-d.scale]
+	} else {
+		den *= pow10[d.scale-scale]
+	}
+
+	return Decimal{value: divRound(num, den), scale: scale}
+}
+
+// Rescale returns the decimal rounded half away from zero or padded to scale decimal places, bounded to 0 to 12
+func (d Decimal) Rescale(scale int) Decimal {
+	scale = boundScale(scale)
+
+	if scale >= d.scale {
+		return Decimal{value: d.value * pow10[scale-d.scale], scale: scale}
+	}
+
+	return Decimal{value: divRound(d.value, pow10[d.scale-scale]), scale: scale}
+}
+
+// MulFloat returns d * f rounded to scale decimal places, bounded to 0 to 12. The product goes through float64 so it
+// is meant for factors that are themselves inexact, such as adjustment factors.
+func (d Decimal) MulFloat(f float64, scale int) Decimal {
+	scale = boundScale(scale)
+
+	return Decimal{value: int64(math.Round(d.Float64() * f * float64(pow10[scale]))), scale: scale}
+}
+
+// boundScale bounds scale to the decimal places a Decimal can hold
+func boundScale(scale int) int {
+	switch {
+	case scale < 0:
+		return 0
+	case scale > _maxScale:
+		return _maxScale
+	default:
+		return scale
+	}
+}
+
+// MarshalJSON encodes the decimal as a string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a decimal from a string or a number, null leaves it unchanged
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+// align returns the decimals at the larger scale of the two
+func align(a, b Decimal) (Decimal, Decimal) {
+	switch {
+	case a.scale < b.scale:
+		return a.Rescale(b.scale), b
+	case a.scale > b.scale:
+		return a, b.Rescale(a.scale)
+	default:
+		return a, b
+	}
+}
+
+// divRound returns num / den rounded half away from zero
+func divRound(num, den int64) int64 {
+	q, r := num/den, num%den
+	if r < 0 {
+		r = -r
+	}
+
+	if 2*r >= abs(den) {
+		if (num < 0) != (den < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+
+	return q
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
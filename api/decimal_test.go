@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected Decimal
+		err      string
+	}{
+		{
+			name:     "keeps the quoted decimal places",
+			value:    "309.3700",
+			expected: NewDecimal(3093700, 4),
+		},
+		{
+			name:     "integer",
+			value:    "4",
+			expected: NewDecimal(4, 0),
+		},
+		{
+			name:     "negative fraction",
+			value:    "-0.05",
+			expected: NewDecimal(-5, 2),
+		},
+		{
+			name:  "exponent",
+			value: "1e3",
+			err:   `invalid decimal "1e3"`,
+		},
+		{
+			name:  "empty",
+			value: "",
+			err:   `invalid decimal ""`,
+		},
+		{
+			name:  "too many decimal places",
+			value: "0.0000000000001",
+			err:   `invalid decimal "0.0000000000001"`,
+		},
+		{
+			name:  "out of range",
+			value: "92233720368547758080",
+			err:   `decimal "92233720368547758080" out of range`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDecimal(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+			assert.Equal(t, tt.value, d.String())
+		})
+	}
+}
+
+func TestDecimal_arithmetic(t *testing.T) {
+	assert.Equal(t, "618.7300", MustParseDecimal("309.42").Add(MustParseDecimal("309.3100")).String())
+	assert.Equal(t, "-1.1100", MustParseDecimal("308.31").Sub(MustParseDecimal("309.4200")).String())
+	assert.Equal(t, "0.05", NewDecimal(5, 2).String())
+	assert.Equal(t, "-0.0500", NewDecimal(-500, 4).String())
+
+	assert.Equal(t, 0, MustParseDecimal("309.42").Cmp(MustParseDecimal("309.4200")))
+	assert.Equal(t, -1, MustParseDecimal("305.54").Cmp(MustParseDecimal("305.5401")))
+	assert.Equal(t, 1, MustParseDecimal("-1").Cmp(MustParseDecimal("-1.5")))
+
+	// half away from zero
+	assert.Equal(t, "313.21", MustParseDecimal("939.6300").Div(3, 2).String())
+	assert.Equal(t, "0.17", MustParseDecimal("0.5").Div(3, 2).String())
+	assert.Equal(t, "-0.2", MustParseDecimal("-0.15").Rescale(1).String())
+	assert.Equal(t, "2.5000", MustParseDecimal("2.5").Rescale(4).String())
+
+	assert.Equal(t, "124.8075", MustParseDecimal("499.2300").MulFloat(0.25, 4).String())
+	assert.Equal(t, 309.42, MustParseDecimal("309.4200").Float64())
+}
+
+func TestDecimal_bounds(t *testing.T) {
+	// dividing by zero gives zero rather than panicking
+	assert.Equal(t, NewDecimal(0, 2), MustParseDecimal("309.42").Div(0, 2))
+
+	// scales beyond the decimal places a Decimal holds are bounded
+	assert.Equal(t, "0.333333333333", MustParseDecimal("1").Div(3, 20).String())
+	assert.Equal(t, "309", MustParseDecimal("309.42").Div(1, -2).String())
+	assert.Equal(t, "309.420000000000", MustParseDecimal("309.42").Rescale(15).String())
+	assert.Equal(t, "309", MustParseDecimal("309.42").Rescale(-1).String())
+	assert.Equal(t, "154.710000000000", MustParseDecimal("309.42").MulFloat(0.5, 13).String())
+	assert.Equal(t, "155", MustParseDecimal("309.42").MulFloat(0.5, -3).String())
+
+	assert.Equal(t, "0.000000000001", NewDecimal(5, 13).String())
+	assert.Equal(t, "500", NewDecimal(5, -2).String())
+	assert.Equal(t, 1e-12, NewDecimal(1, 14).Add(NewDecimal(1, 12)).Float64())
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var price Price
+
+	err := json.Unmarshal([]byte(`{"1. open":"309.3700","2. high":310.13,"3. low":"305.5400","4. close":"309.4200","5. volume":"27110529"}`), &price)
+	assert.NoError(t, err)
+	assert.Equal(t, Price{
+		Open:   NewDecimal(3093700, 4),
+		High:   NewDecimal(31013, 2),
+		Low:    NewDecimal(3055400, 4),
+		Close:  NewDecimal(3094200, 4),
+		Volume: 27110529,
+	}, price)
+
+	encoded, err := json.Marshal(price)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"1. open":"309.3700","2. high":"310.13","3. low":"305.5400","4. close":"309.4200","5. volume":"27110529"}`, string(encoded))
+
+	// prices are validated when they are received
+	err = json.Unmarshal([]byte(`{"1. open":"n/a"}`), &price)
+	assert.EqualError(t, err, `invalid decimal "n/a"`)
+
+	err = json.Unmarshal([]byte(`{"5. volume":"27110529.5"}`), &price)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	// ErrRateLimited is returned when the per minute request limit of the api has been reached
+	ErrRateLimited = errors.New("upstream rate limit reached")
+	// ErrQuotaExceeded is returned when the daily request quota of the api is exhausted
+	ErrQuotaExceeded = errors.New("upstream daily quota exhausted")
+	// ErrInvalidSymbol is returned when the api does not know the requested symbol
+	ErrInvalidSymbol = errors.New("invalid symbol")
+	// ErrInvalidAPIKey is returned when the api rejects the configured api key
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrUpstream is returned for any other error the api reports
+	ErrUpstream = errors.New("upstream error")
+	// ErrTimeout is returned when the api does not answer within the timeout of the client or the deadline of the
+	// request context
+	ErrTimeout = errors.New("upstream timed out")
+)
+
+// UpstreamError is an error reported by the api. Alpha Vantage reports most errors as a 200 response with a
+// "Note", "Information" or "Error Message" field rather than a status code.
+type UpstreamError struct {
+	// Err is one of the sentinel errors above and can be checked with errors.Is
+	Err error
+	// Message is the message returned by the api
+	Message string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Message)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// checkResponse returns an UpstreamError when the response body carries an error instead of prices
+func checkResponse(res *Errors) error {
+	var message string
+
+	switch {
+	case res.ErrorMessage != "":
+		message = res.ErrorMessage
+	case res.Note != "":
+		message = res.Note
+	case res.Information != "":
+		message = res.Information
+	default:
+		return nil
+	}
+
+	lower := strings.ToLower(message)
+
+	switch {
+	case strings.Contains(lower, "apikey") || strings.Contains(lower, "api key"):
+		return &UpstreamError{Err: ErrInvalidAPIKey, Message: message}
+	case res.ErrorMessage != "": // "Invalid API call" is returned for unknown symbols
+		return &UpstreamError{Err: ErrInvalidSymbol, Message: message}
+	case strings.Contains(lower, "per minute"):
+		return &UpstreamError{Err: ErrRateLimited, Message: message}
+	case strings.Contains(lower, "per day") || strings.Contains(lower, "daily"):
+		return &UpstreamError{Err: ErrQuotaExceeded, Message: message}
+	default:
+		return &UpstreamError{Err: ErrUpstream, Message: message}
+	}
+}
+
+// requestError returns an UpstreamError of ErrTimeout when err is the api not answering within the client timeout. The
+// context of the caller ending is left as its context.DeadlineExceeded or context.Canceled.
+func requestError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var netErr net.Error
+
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &UpstreamError{Err: ErrTimeout, Message: err.Error()}
+	}
+
+	return err
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Failover is a provider that reads from its primary provider and falls back to its secondary one when the primary
+// is throttled, out of quota or failing. The series returned names the provider it was actually read from.
+type Failover struct {
+	primary   Provider
+	secondary Provider
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Provider = (*Failover)(nil)
+
+// NewFailover returns a provider reading from primary and falling back to secondary
+func NewFailover(primary, secondary Provider) *Failover {
+	return &Failover{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+// Name returns the name of the primary provider, the one configured for the symbols it provides
+func (f *Failover) Name() string {
+	return f.primary.Name()
+}
+
+// Daily gets the daily series of symbol from the primary provider or else from the secondary one. The error of the
+// primary is returned when both fail so the reason the symbol could not be served is not masked.
+func (f *Failover) Daily(ctx context.Context, symbol string, size OutputSize) (*Series, error) {
+	series, err := f.primary.Daily(ctx, symbol, size)
+	// a request given up by its caller is not worth asking another provider
+	if err == nil || ctx.Err() != nil {
+		return series, err
+	}
+
+	log.Warn().Err(err).
+		Str("symbol", symbol).
+		Str("primary", f.primary.Name()).
+		Str("secondary", f.secondary.Name()).
+		Msg("primary provider failed, falling back")
+
+	series, fallbackErr := f.secondary.Daily(ctx, symbol, size)
+	if fallbackErr != nil {
+		log.Error().Err(fallbackErr).Str("symbol", symbol).Str("secondary", f.secondary.Name()).Msg("secondary provider failed")
+
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// WithFallback returns the providers with each provider falling back to secondary, but secondary itself
+func (p *Providers) WithFallback(secondary Provider) *Providers {
+	wrap := func(provider Provider) Provider {
+		if provider.Name() == secondary.Name() {
+			return provider
+		}
+
+		return NewFailover(provider, secondary)
+	}
+
+	bySymbol := make(map[string]Provider, len(p.bySymbol))
+	for symbol, provider := range p.bySymbol {
+		bySymbol[symbol] = wrap(provider)
+	}
+
+	return &Providers{
+		def:      wrap(p.def),
+		bySymbol: bySymbol,
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProvider returns the same series or error for every symbol and counts its calls
+type stubProvider struct {
+	name   string
+	series *Series
+	err    error
+	calls  int
+}
+
+func (s *stubProvider) Name() string {
+	return s.name
+}
+
+func (s *stubProvider) Daily(ctx context.Context, symbol string, size OutputSize) (*Series, error) {
+	s.calls++
+
+	return s.series, s.err
+}
+
+func TestFailover_Daily(t *testing.T) {
+	primarySeries := &Series{Symbol: "IBM", Source: AlphaVantage}
+	secondarySeries := &Series{Symbol: "IBM", Source: CSV}
+
+	throttled := &UpstreamError{Err: ErrRateLimited, Message: "5 calls per minute"}
+
+	tests := []struct {
+		name           string
+		primaryErr     error
+		secondaryErr   error
+		cancelled      bool
+		expected       *Series
+		err            error
+		secondaryCalls int
+	}{
+		{
+			name:     "reads from the primary",
+			expected: primarySeries,
+		},
+		{
+			name:           "falls back when the primary is throttled",
+			primaryErr:     throttled,
+			expected:       secondarySeries,
+			secondaryCalls: 1,
+		},
+		{
+			name:           "falls back when the primary is out of quota",
+			primaryErr:     &UpstreamError{Err: ErrQuotaExceeded, Message: "500 requests per day"},
+			expected:       secondarySeries,
+			secondaryCalls: 1,
+		},
+		{
+			name:           "returns the error of the primary when both fail",
+			primaryErr:     throttled,
+			secondaryErr:   errors.New("connection refused"),
+			err:            ErrRateLimited,
+			secondaryCalls: 1,
+		},
+		{
+			name:       "does not fall back once the request is cancelled",
+			primaryErr: context.Canceled,
+			cancelled:  true,
+			err:        context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &stubProvider{name: AlphaVantage, series: primarySeries, err: tt.primaryErr}
+			secondary := &stubProvider{name: CSV, series: secondarySeries, err: tt.secondaryErr}
+
+			if tt.primaryErr != nil {
+				primary.series = nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancelled {
+				cancel()
+			}
+
+			failover := NewFailover(primary, secondary)
+
+			series, err := failover.Daily(ctx, "IBM", Compact)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, AlphaVantage, failover.Name())
+			assert.Equal(t, tt.expected, series)
+			assert.Equal(t, tt.secondaryCalls, secondary.calls)
+		})
+	}
+}
+
+func TestProviders_WithFallback(t *testing.T) {
+	av := &stubProvider{name: AlphaVantage}
+	csv := &stubProvider{name: CSV}
+
+	providers := NewProviders(av, map[string]Provider{"BRK.B": csv}).WithFallback(csv)
+
+	assert.Equal(t, NewFailover(av, csv), providers.For("IBM"))
+	// a symbol already read from the fallback has nothing to fall back to
+	assert.Same(t, csv, providers.For("BRK.B"))
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// IntradayFormat is the layout of the timestamps of intraday bars
+	IntradayFormat = "2006-01-02 15:04:05"
+
+	_timeSeriesPrefix = "Time Series"
+)
+
+// Interval is the time between two intraday bars
+type Interval string
+
+const (
+	OneMinute      Interval = "1min"
+	FiveMinutes    Interval = "5min"
+	FifteenMinutes Interval = "15min"
+	ThirtyMinutes  Interval = "30min"
+	SixtyMinutes   Interval = "60min"
+)
+
+var intervals = map[Interval]time.Duration{
+	OneMinute:      time.Minute,
+	FiveMinutes:    5 * time.Minute,
+	FifteenMinutes: 15 * time.Minute,
+	ThirtyMinutes:  30 * time.Minute,
+	SixtyMinutes:   time.Hour,
+}
+
+// ParseInterval returns the interval named s
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(s)
+	if _, ok := intervals[interval]; !ok {
+		return "", fmt.Errorf("invalid interval %q: expected one of 1min, 5min, 15min, 30min or 60min", s)
+	}
+
+	return interval, nil
+}
+
+// Duration returns the time between two bars
+func (i Interval) Duration() time.Duration {
+	return intervals[i]
+}
+
+// IntradayResponse is the response of TIME_SERIES_INTRADAY. The series is keyed by its interval e.g.
+// "Time Series (5min)" so it is decoded by UnmarshalJSON.
+type IntradayResponse struct {
+	MetaData IntradayMD
+	Prices   map[string]Price
+	Errors
+}
+
+type IntradayMD struct {
+	Information   string `json:"1. Information,omitempty"`
+	Symbol        string `json:"2. Symbol,omitempty"`
+	LastRefreshed string `json:"3. Last Refreshed,omitempty"`
+	Interval      string `json:"4. Interval,omitempty"`
+	OutputSize    string `json:"5. Output Size,omitempty"`
+	TimeZone      string `json:"6. Time Zone,omitempty"`
+}
+
+func (r *IntradayResponse) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &r.Errors); err != nil {
+		return err
+	}
+
+	for key, value := range fields {
+		switch {
+		case key == "Meta Data":
+			if err := json.Unmarshal(value, &r.MetaData); err != nil {
+				return err
+			}
+		case strings.HasPrefix(key, _timeSeriesPrefix):
+			if err := json.Unmarshal(value, &r.Prices); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// IntradayBar is the price over an interval starting at Timestamp
+type IntradayBar struct {
+	Timestamp time.Time `json:"Timestamp"`
+	Price     *Price    `json:"Price,omitempty"`
+}
+
+// OrderedIntraday is the latest bars of a symbol, latest first
+type OrderedIntraday struct {
+	Symbol   string         `json:"Symbol"`
+	Interval Interval       `json:"Interval"`
+	Bars     []*IntradayBar `json:"Bars"`
+}
+
+// Bars returns the bars latest first with their timestamps in the time zone of the series
+func (r *IntradayResponse) Bars() ([]*IntradayBar, error) {
+	loc := time.UTC
+
+	if r.MetaData.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(r.MetaData.TimeZone); err != nil {
+			return nil, fmt.Errorf("loading time zone: %w", err)
+		}
+	}
+
+	bars := make([]*IntradayBar, 0, len(r.Prices))
+
+	for timestamp, price := range r.Prices {
+		t, err := time.ParseInLocation(IntradayFormat, timestamp, loc)
+		if err != nil {
+			return nil, fmt.Errorf("converting intraday time series: %w", err)
+		}
+
+		price := price
+
+		bars = append(bars, &IntradayBar{
+			Timestamp: t,
+			Price:     &price,
+		})
+	}
+
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Timestamp.After(bars[j].Timestamp)
+	})
+
+	return bars, nil
+}
+
+// GetIntraday gets the intraday bars of symbol at interval, compact for the latest 100 bars or full for the
+// trailing 30 days
+func (c *Client) GetIntraday(ctx context.Context, symbol string, interval Interval, size OutputSize) (*IntradayResponse, error) {
+	requestURL := fmt.Sprintf("%s?apikey=%s&function=TIME_SERIES_INTRADAY&symbol=%s&interval=%s&datatype=json&outputsize=%s", c.options.baseURL, c.options.apiKey, url.QueryEscape(symbol), interval, size)
+
+	respBody, err := c.performRequest(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = respBody.Close()
+	}()
+
+	body, err := ioutil.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var res IntradayResponse
+
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if err = checkResponse(&res.Errors); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetIntraday(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "TIME_SERIES_INTRADAY", r.URL.Query().Get("function"))
+		assert.Equal(t, "15min", r.URL.Query().Get("interval"))
+
+		_, _ = w.Write([]byte(`{
+    "Meta Data": {
+        "1. Information": "Intraday (15min) open, high, low, close prices and volume",
+        "2. Symbol": "IBM",
+        "3. Last Refreshed": "2022-04-01 20:00:00",
+        "4. Interval": "15min",
+        "5. Output Size": "Compact",
+        "6. Time Zone": "US/Eastern"
+    },
+    "Time Series (15min)": {
+        "2022-04-01 19:45:00": {
+            "1. open": "130.1500",
+            "2. high": "130.1500",
+            "3. low": "130.1500",
+            "4. close": "130.1500",
+            "5. volume": "200"
+        },
+        "2022-04-01 20:00:00": {
+            "1. open": "130.2000",
+            "2. high": "130.2000",
+            "3. low": "130.1000",
+            "4. close": "130.1000",
+            "5. volume": "310"
+        }
+    }
+}`))
+	}))
+	defer srv.Close()
+
+	client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(time.Second))
+
+	res, err := client.GetIntraday(context.Background(), "IBM", FifteenMinutes, Compact)
+	assert.NoError(t, err)
+	assert.Equal(t, "15min", res.MetaData.Interval)
+
+	bars, err := res.Bars()
+	assert.NoError(t, err)
+
+	newYork, err := time.LoadLocation("US/Eastern")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*IntradayBar{
+		{
+			Timestamp: time.Date(2022, 4, 1, 20, 0, 0, 0, newYork),
+			Price:     &Price{Open: MustParseDecimal("130.2000"), High: MustParseDecimal("130.2000"), Low: MustParseDecimal("130.1000"), Close: MustParseDecimal("130.1000"), Volume: 310},
+		},
+		{
+			Timestamp: time.Date(2022, 4, 1, 19, 45, 0, 0, newYork),
+			Price:     &Price{Open: MustParseDecimal("130.1500"), High: MustParseDecimal("130.1500"), Low: MustParseDecimal("130.1500"), Close: MustParseDecimal("130.1500"), Volume: 200},
+		},
+	}, bars)
+}
+
+func TestParseInterval(t *testing.T) {
+	interval, err := ParseInterval("60min")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, interval.Duration())
+
+	_, err = ParseInterval("2min")
+	assert.EqualError(t, err, `invalid interval "2min": expected one of 1min, 5min, 15min, 30min or 60min`)
+}
@@ -12,6 +12,58 @@ import (
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockProvider is a mock of Provider interface.
+type MockProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockProviderMockRecorder
+}
+
+// MockProviderMockRecorder is the mock recorder for MockProvider.
+type MockProviderMockRecorder struct {
+	mock *MockProvider
+}
+
+// NewMockProvider creates a new mock instance.
+func NewMockProvider(ctrl *gomock.Controller) *MockProvider {
+	mock := &MockProvider{ctrl: ctrl}
+	mock.recorder = &MockProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
+	return m.recorder
+}
+
+// Daily mocks base method.
+func (m *MockProvider) Daily(ctx context.Context, symbol string, size api.OutputSize) (*api.Series, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Daily", ctx, symbol, size)
+	ret0, _ := ret[0].(*api.Series)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Daily indicates an expected call of Daily.
+func (mr *MockProviderMockRecorder) Daily(ctx, symbol, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Daily", reflect.TypeOf((*MockProvider)(nil).Daily), ctx, symbol, size)
+}
+
+// Name mocks base method.
+func (m *MockProvider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockProviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockProvider)(nil).Name))
+}
+
 // MockAPI is a mock of API interface.
 type MockAPI struct {
 	ctrl     *gomock.Controller
@@ -35,32 +87,149 @@ func (m *MockAPI) EXPECT() *MockAPIMockRecorder {
 	return m.recorder
 }
 
+// Daily mocks base method.
+func (m *MockAPI) Daily(ctx context.Context, symbol string, size api.OutputSize) (*api.Series, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Daily", ctx, symbol, size)
+	ret0, _ := ret[0].(*api.Series)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Daily indicates an expected call of Daily.
+func (mr *MockAPIMockRecorder) Daily(ctx, symbol, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Daily", reflect.TypeOf((*MockAPI)(nil).Daily), ctx, symbol, size)
+}
+
+// GetAdjustedSeries mocks base method.
+func (m *MockAPI) GetAdjustedSeries(ctx context.Context, symbol string, size api.OutputSize) (*api.AdjustedResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdjustedSeries", ctx, symbol, size)
+	ret0, _ := ret[0].(*api.AdjustedResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdjustedSeries indicates an expected call of GetAdjustedSeries.
+func (mr *MockAPIMockRecorder) GetAdjustedSeries(ctx, symbol, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdjustedSeries", reflect.TypeOf((*MockAPI)(nil).GetAdjustedSeries), ctx, symbol, size)
+}
+
 // GetAllPrices mocks base method.
-func (m *MockAPI) GetAllPrices(ctx context.Context) (*api.JSONResponse, error) {
+func (m *MockAPI) GetAllPrices(ctx context.Context, symbol string) (*api.JSONResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPrices", ctx)
+	ret := m.ctrl.Call(m, "GetAllPrices", ctx, symbol)
 	ret0, _ := ret[0].(*api.JSONResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllPrices indicates an expected call of GetAllPrices.
-func (mr *MockAPIMockRecorder) GetAllPrices(ctx interface{}) *gomock.Call {
+func (mr *MockAPIMockRecorder) GetAllPrices(ctx, symbol interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPrices", reflect.TypeOf((*MockAPI)(nil).GetAllPrices), ctx, symbol)
+}
+
+// GetIntraday mocks base method.
+func (m *MockAPI) GetIntraday(ctx context.Context, symbol string, interval api.Interval, size api.OutputSize) (*api.IntradayResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIntraday", ctx, symbol, interval, size)
+	ret0, _ := ret[0].(*api.IntradayResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIntraday indicates an expected call of GetIntraday.
+func (mr *MockAPIMockRecorder) GetIntraday(ctx, symbol, interval, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIntraday", reflect.TypeOf((*MockAPI)(nil).GetIntraday), ctx, symbol, interval, size)
+}
+
+// GetPeriodSeries mocks base method.
+func (m *MockAPI) GetPeriodSeries(ctx context.Context, symbol string, period api.Period) (*api.JSONResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeriodSeries", ctx, symbol, period)
+	ret0, _ := ret[0].(*api.JSONResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeriodSeries indicates an expected call of GetPeriodSeries.
+func (mr *MockAPIMockRecorder) GetPeriodSeries(ctx, symbol, period interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPrices", reflect.TypeOf((*MockAPI)(nil).GetAllPrices), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeriodSeries", reflect.TypeOf((*MockAPI)(nil).GetPeriodSeries), ctx, symbol, period)
 }
 
 // GetPrices mocks base method.
-func (m *MockAPI) GetPrices(ctx context.Context) (*api.OrderedResponse, error) {
+func (m *MockAPI) GetPrices(ctx context.Context, symbol string, days int) (*api.OrderedResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrices", ctx)
+	ret := m.ctrl.Call(m, "GetPrices", ctx, symbol, days)
 	ret0, _ := ret[0].(*api.OrderedResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrices indicates an expected call of GetPrices.
-func (mr *MockAPIMockRecorder) GetPrices(ctx interface{}) *gomock.Call {
+func (mr *MockAPIMockRecorder) GetPrices(ctx, symbol, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrices", reflect.TypeOf((*MockAPI)(nil).GetPrices), ctx, symbol, days)
+}
+
+// GetSeries mocks base method.
+func (m *MockAPI) GetSeries(ctx context.Context, symbol string, size api.OutputSize) (*api.JSONResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeries", ctx, symbol, size)
+	ret0, _ := ret[0].(*api.JSONResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeries indicates an expected call of GetSeries.
+func (mr *MockAPIMockRecorder) GetSeries(ctx, symbol, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeries", reflect.TypeOf((*MockAPI)(nil).GetSeries), ctx, symbol, size)
+}
+
+// Health mocks base method.
+func (m *MockAPI) Health() api.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Health")
+	ret0, _ := ret[0].(api.Health)
+	return ret0
+}
+
+// Health indicates an expected call of Health.
+func (mr *MockAPIMockRecorder) Health() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockAPI)(nil).Health))
+}
+
+// Name mocks base method.
+func (m *MockAPI) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockAPIMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockAPI)(nil).Name))
+}
+
+// Quota mocks base method.
+func (m *MockAPI) Quota() *api.Quota {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quota")
+	ret0, _ := ret[0].(*api.Quota)
+	return ret0
+}
+
+// Quota indicates an expected call of Quota.
+func (mr *MockAPIMockRecorder) Quota() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrices", reflect.TypeOf((*MockAPI)(nil).GetPrices), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quota", reflect.TypeOf((*MockAPI)(nil).Quota))
 }
@@ -3,6 +3,14 @@ package api
 type JSONResponse struct {
 	MetaData    MD              `json:"Meta Data"`
 	DailyPrices TimeSeriesDaily `json:"Time Series (Daily)"`
+	Errors
+}
+
+// Errors are reported in the body of a 200 response under one of these fields
+type Errors struct {
+	Note         string `json:"Note,omitempty"`
+	Information  string `json:"Information,omitempty"`
+	ErrorMessage string `json:"Error Message,omitempty"`
 }
 
 type MD struct {
@@ -13,12 +21,13 @@ type MD struct {
 	TimeZone      string `json:"5. Time Zone,omitempty"`
 }
 
+// Price is the OHLCV of a bar, prices keep the decimal places the api quotes them with
 type Price struct {
-	Open   string `json:"1. open,omitempty"`
-	High   string `json:"2. high,omitempty"`
-	Low    string `json:"3. low,omitempty"`
-	Close  string `json:"4. close,omitempty"`
-	Volume string `json:"5. volume,omitempty"`
+	Open   Decimal `json:"1. open"`
+	High   Decimal `json:"2. high"`
+	Low    Decimal `json:"3. low"`
+	Close  Decimal `json:"4. close"`
+	Volume int64   `json:"5. volume,string"`
 }
 
 type TimeSeriesDaily map[string]Price
@@ -32,3 +41,34 @@ type OrderedResponse struct {
 	DailyPrices     []*DailyPrice `json:"Daily Price,omitempty"`
 	AvgClosingPrice float64       `json:"Average Closing Price,omitempty"`
 }
+
+// AdjustedResponse is the response of TIME_SERIES_DAILY_ADJUSTED
+type AdjustedResponse struct {
+	MetaData    MD                      `json:"Meta Data"`
+	DailyPrices TimeSeriesDailyAdjusted `json:"Time Series (Daily)"`
+	Errors
+}
+
+type AdjustedPrice struct {
+	Open             Decimal `json:"1. open"`
+	High             Decimal `json:"2. high"`
+	Low              Decimal `json:"3. low"`
+	Close            Decimal `json:"4. close"`
+	AdjustedClose    Decimal `json:"5. adjusted close"`
+	Volume           int64   `json:"6. volume,string"`
+	DividendAmount   Decimal `json:"7. dividend amount"`
+	SplitCoefficient Decimal `json:"8. split coefficient"`
+}
+
+type TimeSeriesDailyAdjusted map[string]AdjustedPrice
+
+// CorporateAction is a dividend or split taking effect on a day
+type CorporateAction struct {
+	DividendAmount   Decimal `json:"dividend_amount"`
+	SplitCoefficient Decimal `json:"split_coefficient"`
+	// Factor is what prices before the day are multiplied by to adjust them for the action
+	Factor float64 `json:"factor"`
+}
+
+// CorporateActions are the actions keyed by the day they take effect
+type CorporateActions map[string]CorporateAction
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -8,9 +9,9 @@ type options struct {
 	baseURL    string
 	timeout    time.Duration
 	maxRetries int
-	symbol     string
-	nDays      int
 	apiKey     string
+	quota      *Quota
+	transport  http.RoundTripper
 }
 
 // WithKey sets symbol in get request
@@ -20,20 +21,6 @@ func WithKey(s string) Option {
 	}
 }
 
-// WithDays num of days data client should retrieve
-func WithDays(d int) Option {
-	return func(a API) {
-		a.(*Client).options.nDays = d
-	}
-}
-
-// WithSymbol sets symbol in get request
-func WithSymbol(s string) Option {
-	return func(a API) {
-		a.(*Client).options.symbol = s
-	}
-}
-
 // WithBaseURL sets base URL path for requests
 func WithBaseURL(url string) Option {
 	return func(a API) {
@@ -54,3 +41,17 @@ func WithMaxRetries(retries int) Option {
 		a.(*Client).options.maxRetries = retries
 	}
 }
+
+// WithQuota schedules requests within the quota
+func WithQuota(q *Quota) Option {
+	return func(a API) {
+		a.(*Client).options.quota = q
+	}
+}
+
+// WithTransport sends requests with the transport, such as a Cassette, rather than the default one
+func WithTransport(rt http.RoundTripper) Option {
+	return func(a API) {
+		a.(*Client).options.transport = rt
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AlphaVantage is the name of the Alpha Vantage provider
+const AlphaVantage = "alpha_vantage"
+
+// Series is a daily price series normalized across providers
+type Series struct {
+	Symbol string
+	// Source is the name of the provider of the series
+	Source string
+	// LastRefreshed is when the provider last updated the series, the latest day when it does not say
+	LastRefreshed string
+	// Prices are latest first
+	Prices []*DailyPrice
+}
+
+// NewSeries normalizes a response in the Alpha Vantage shape
+func NewSeries(symbol, source string, resp *JSONResponse) *Series {
+	series := &Series{
+		Symbol:        symbol,
+		Source:        source,
+		LastRefreshed: resp.MetaData.LastRefreshed,
+		Prices:        Ordered(resp.DailyPrices, time.Time{}, time.Time{}),
+	}
+
+	if series.LastRefreshed == "" && len(series.Prices) != 0 {
+		series.LastRefreshed = series.Prices[0].Day
+	}
+
+	return series
+}
+
+// Response returns the series in the shape prices are stored in
+func (s *Series) Response() *JSONResponse {
+	resp := &JSONResponse{
+		MetaData: MD{
+			Symbol:        s.Symbol,
+			LastRefreshed: s.LastRefreshed,
+		},
+		DailyPrices: make(TimeSeriesDaily, len(s.Prices)),
+	}
+
+	for _, price := range s.Prices {
+		resp.DailyPrices[price.Day] = *price.Price
+	}
+
+	return resp
+}
+
+// Latest returns the latest days prices of the series along with their average close
+func (s *Series) Latest(days int) *OrderedResponse {
+	prices := s.Prices
+	if days > 0 && len(prices) > days {
+		prices = prices[:days]
+	}
+
+	return &OrderedResponse{
+		DailyPrices:     prices,
+		AvgClosingPrice: AverageClose(prices),
+	}
+}
+
+// Name returns the name of the Alpha Vantage provider
+func (c *Client) Name() string {
+	return AlphaVantage
+}
+
+// Daily gets the TIME_SERIES_DAILY series of symbol
+func (c *Client) Daily(ctx context.Context, symbol string, size OutputSize) (*Series, error) {
+	resp, err := c.GetSeries(ctx, symbol, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSeries(symbol, AlphaVantage, resp), nil
+}
+
+// Providers selects the provider of each symbol, symbols without one of their own use the default provider
+type Providers struct {
+	def      Provider
+	bySymbol map[string]Provider
+}
+
+// NewProviders selects the providers of the symbols in bySymbol and def for any other symbol
+func NewProviders(def Provider, bySymbol map[string]Provider) *Providers {
+	normalized := make(map[string]Provider, len(bySymbol))
+	for symbol, provider := range bySymbol {
+		normalized[strings.ToUpper(symbol)] = provider
+	}
+
+	return &Providers{
+		def:      def,
+		bySymbol: normalized,
+	}
+}
+
+// ParseProviders selects the providers named in spec, a comma separated list of SYMBOL=provider pairs, and the
+// provider named def for any other symbol e.g. ParseProviders("alpha_vantage", "BRK.B=csv,VOD.L=csv", av, csv)
+func ParseProviders(def, spec string, available ...Provider) (*Providers, error) {
+	byName := make(map[string]Provider, len(available))
+	for _, provider := range available {
+		byName[provider.Name()] = provider
+	}
+
+	defProvider, ok := byName[def]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", def)
+	}
+
+	bySymbol := make(map[string]Provider)
+
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid provider selection %q: expected SYMBOL=provider", pair)
+		}
+
+		provider, ok := byName[strings.TrimSpace(parts[1])]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q for %s", strings.TrimSpace(parts[1]), strings.TrimSpace(parts[0]))
+		}
+
+		bySymbol[strings.TrimSpace(parts[0])] = provider
+	}
+
+	return NewProviders(defProvider, bySymbol), nil
+}
+
+// ProviderNamed returns the provider named name among the available ones
+func ProviderNamed(name string, available ...Provider) (Provider, error) {
+	for _, provider := range available {
+		if provider.Name() == name {
+			return provider, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown provider %q", name)
+}
+
+// For returns the provider of symbol
+func (p *Providers) For(symbol string) Provider {
+	if provider, ok := p.bySymbol[strings.ToUpper(symbol)]; ok {
+		return provider
+	}
+
+	return p.def
+}
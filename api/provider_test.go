@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Daily(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "TIME_SERIES_DAILY", r.URL.Query().Get("function"))
+		assert.Equal(t, "full", r.URL.Query().Get("outputsize"))
+
+		_, _ = w.Write([]byte(`{
+    "Meta Data": {"2. Symbol": "IBM", "3. Last Refreshed": "2022-04-01"},
+    "Time Series (Daily)": {
+        "2022-03-31": {"1. open": "131.4000", "2. high": "131.8200", "3. low": "129.8900", "4. close": "130.0200", "5. volume": "4365441"},
+        "2022-04-01": {"1. open": "129.6600", "2. high": "130.2700", "3. low": "128.0600", "4. close": "130.1500", "5. volume": "4012373"}
+    }
+}`))
+	}))
+	defer srv.Close()
+
+	client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(time.Second))
+
+	series, err := client.Daily(context.Background(), "IBM", Full)
+	assert.NoError(t, err)
+
+	latest := &DailyPrice{Day: "2022-04-01", Price: &Price{Open: MustParseDecimal("129.6600"), High: MustParseDecimal("130.2700"), Low: MustParseDecimal("128.0600"), Close: MustParseDecimal("130.1500"), Volume: 4012373}}
+
+	assert.Equal(t, AlphaVantage, client.Name())
+	assert.Equal(t, "IBM", series.Symbol)
+	assert.Equal(t, AlphaVantage, series.Source)
+	assert.Equal(t, "2022-04-01", series.LastRefreshed)
+	assert.Len(t, series.Prices, 2)
+	assert.Equal(t, latest, series.Prices[0])
+
+	assert.Equal(t, &OrderedResponse{DailyPrices: []*DailyPrice{latest}, AvgClosingPrice: 130.15}, series.Latest(1))
+
+	resp := series.Response()
+	assert.Equal(t, "2022-04-01", resp.MetaData.LastRefreshed)
+	assert.Equal(t, *latest.Price, resp.DailyPrices["2022-04-01"])
+	assert.Len(t, resp.DailyPrices, 2)
+}
+
+func TestParseProviders(t *testing.T) {
+	av, csv := New(), NewCSV()
+
+	tests := []struct {
+		name     string
+		def      string
+		spec     string
+		expected map[string]Provider
+		err      string
+	}{
+		{
+			name:     "default only",
+			def:      AlphaVantage,
+			expected: map[string]Provider{"IBM": av, "BRK.B": av},
+		},
+		{
+			name:     "per symbol",
+			def:      AlphaVantage,
+			spec:     "brk.b=csv, VOD.L = csv",
+			expected: map[string]Provider{"IBM": av, "BRK.B": csv, "vod.l": csv},
+		},
+		{
+			name: "unknown default",
+			def:  "yahoo",
+			err:  `unknown provider "yahoo"`,
+		},
+		{
+			name: "unknown provider",
+			def:  AlphaVantage,
+			spec: "IBM=yahoo",
+			err:  `unknown provider "yahoo" for IBM`,
+		},
+		{
+			name: "invalid pair",
+			def:  AlphaVantage,
+			spec: "IBM",
+			err:  `invalid provider selection "IBM": expected SYMBOL=provider`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, err := ParseProviders(tt.def, tt.spec, av, csv)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			for symbol, provider := range tt.expected {
+				assert.Same(t, provider, providers.For(symbol), symbol)
+			}
+		})
+	}
+}
+
+func TestProviderNamed(t *testing.T) {
+	av, csv := New(), NewCSV()
+
+	provider, err := ProviderNamed(CSV, av, csv)
+	assert.NoError(t, err)
+	assert.Same(t, csv, provider)
+
+	_, err = ProviderNamed("yahoo", av, csv)
+	assert.EqualError(t, err, `unknown provider "yahoo"`)
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPerMinute is the number of requests the api allows per minute on the free plan
+	DefaultPerMinute = 5
+	// DefaultPerDay is the number of requests the api allows per day on the free plan
+	DefaultPerDay = 500
+)
+
+// QuotaCounter persists the number of requests made on a day, sharing one counter between replicas keeps them
+// all within the daily quota
+type QuotaCounter interface {
+	// Incr increments the number of requests made on day and returns the new count
+	Incr(ctx context.Context, day string) (int, error)
+	// Get returns the number of requests made on day
+	Get(ctx context.Context, day string) (int, error)
+}
+
+// QuotaStatus is the quota left at a point in time
+type QuotaStatus struct {
+	PerMinute       int       `json:"per_minute"`
+	PerDay          int       `json:"per_day"`
+	MinuteRemaining int       `json:"minute_remaining"`
+	DayRemaining    int       `json:"day_remaining"`
+	DayResetsAt     time.Time `json:"day_resets_at"`
+}
+
+// QuotaOption specifies a builder function for configuring a Quota
+type QuotaOption func(*Quota)
+
+// Quota schedules outbound requests within the per minute and per day limits of the api. Each window is a token
+// bucket, the minute bucket refills continuously and the day bucket refills at midnight UTC. The minute bucket is
+// local to the process while the day bucket is kept by a QuotaCounter so it can be shared.
+type Quota struct {
+	mu sync.Mutex
+
+	perMinute int
+	perDay    int
+	queue     bool
+	counter   QuotaCounter
+	now       func() time.Time
+
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewQuota initializes a quota with the free plan limits that queues requests when the minute budget is used
+func NewQuota(opts ...QuotaOption) *Quota {
+	q := &Quota{
+		perMinute: DefaultPerMinute,
+		perDay:    DefaultPerDay,
+		queue:     true,
+		counter:   newMemoryCounter(),
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.tokens = float64(q.perMinute)
+	q.lastRefill = q.now()
+
+	return q
+}
+
+// WithPerMinute sets the number of requests allowed per minute, n <= 0 leaving the free plan limit
+func WithPerMinute(n int) QuotaOption {
+	return func(q *Quota) {
+		if n > 0 {
+			q.perMinute = n
+		}
+	}
+}
+
+// WithPerDay sets the number of requests allowed per day, n <= 0 leaving the free plan limit
+func WithPerDay(n int) QuotaOption {
+	return func(q *Quota) {
+		if n > 0 {
+			q.perDay = n
+		}
+	}
+}
+
+// WithQueue sets whether requests wait for the minute budget to refill or are rejected with ErrRateLimited
+func WithQueue(queue bool) QuotaOption {
+	return func(q *Quota) {
+		q.queue = queue
+	}
+}
+
+// WithCounter sets where the number of requests made each day is kept
+func WithCounter(counter QuotaCounter) QuotaOption {
+	return func(q *Quota) {
+		q.counter = counter
+	}
+}
+
+// Acquire takes a request from both budgets. When the minute budget is used it waits for a token if queueing,
+// otherwise it returns ErrRateLimited. When the daily budget is used it returns ErrQuotaExceeded.
+func (q *Quota) Acquire(ctx context.Context) error {
+	for {
+		wait, err := q.tryAcquire(ctx)
+		if err != nil || wait == 0 {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryAcquire takes a request from both budgets or returns how long to wait for the next minute token. The daily
+// counter may be a round trip to redis so it is incremented without holding the lock, the minute token being given
+// back when the request is not counted.
+func (q *Quota) tryAcquire(ctx context.Context) (time.Duration, error) {
+	now, wait, err := q.takeToken()
+	if err != nil || wait != 0 {
+		return wait, err
+	}
+
+	used, err := q.counter.Incr(ctx, quotaDay(now))
+	if err != nil {
+		q.giveBackToken()
+
+		return 0, fmt.Errorf("increment daily quota: %w", err)
+	}
+
+	if used > q.perDay {
+		q.giveBackToken()
+
+		return 0, &UpstreamError{Err: ErrQuotaExceeded, Message: fmt.Sprintf("client quota of %d requests per day used", q.perDay)}
+	}
+
+	return 0, nil
+}
+
+// takeToken takes a minute token or returns how long to wait for the next one
+func (q *Quota) takeToken() (time.Time, time.Duration, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.refill(now)
+
+	if q.tokens < 1 {
+		if !q.queue {
+			return now, 0, &UpstreamError{Err: ErrRateLimited, Message: fmt.Sprintf("client quota of %d requests per minute used", q.perMinute)}
+		}
+
+		return now, time.Duration((1 - q.tokens) / q.rate()), nil
+	}
+
+	q.tokens--
+
+	return now, 0, nil
+}
+
+// giveBackToken returns a minute token taken for a request that was not made
+func (q *Quota) giveBackToken() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tokens++
+	if q.tokens > float64(q.perMinute) {
+		q.tokens = float64(q.perMinute)
+	}
+}
+
+// Remaining returns the quota left in both windows
+func (q *Quota) Remaining(ctx context.Context) (QuotaStatus, error) {
+	q.mu.Lock()
+	now := q.now()
+	q.refill(now)
+	minuteRemaining := int(q.tokens)
+	q.mu.Unlock()
+
+	used, err := q.counter.Get(ctx, quotaDay(now))
+	if err != nil {
+		return QuotaStatus{}, fmt.Errorf("read daily quota: %w", err)
+	}
+
+	dayRemaining := q.perDay - used
+	if dayRemaining < 0 {
+		dayRemaining = 0
+	}
+
+	return QuotaStatus{
+		PerMinute:       q.perMinute,
+		PerDay:          q.perDay,
+		MinuteRemaining: minuteRemaining,
+		DayRemaining:    dayRemaining,
+		DayResetsAt:     now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour),
+	}, nil
+}
+
+// refill adds the minute tokens accrued since the last refill
+func (q *Quota) refill(now time.Time) {
+	q.tokens += float64(now.Sub(q.lastRefill)) * q.rate()
+	if q.tokens > float64(q.perMinute) {
+		q.tokens = float64(q.perMinute)
+	}
+
+	q.lastRefill = now
+}
+
+// rate is the number of minute tokens accrued per nanosecond
+func (q *Quota) rate() float64 {
+	return float64(q.perMinute) / float64(time.Minute)
+}
+
+// quotaDay is the key of the day bucket, the daily quota resets at midnight UTC
+func quotaDay(t time.Time) string {
+	return t.UTC().Format(Format)
+}
+
+// quotaTransport takes a request from the quota before every attempt, retries included
+type quotaTransport struct {
+	quota *Quota
+	next  http.RoundTripper
+}
+
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.quota.Acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// memoryCounter keeps the daily count in the process
+type memoryCounter struct {
+	mu   sync.Mutex
+	days map[string]int
+}
+
+func newMemoryCounter() *memoryCounter {
+	return &memoryCounter{days: make(map[string]int)}
+}
+
+func (m *memoryCounter) Incr(_ context.Context, day string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// only the current day is ever needed
+	for d := range m.days {
+		if d != day {
+			delete(m.days, d)
+		}
+	}
+
+	m.days[day]++
+
+	return m.days[day], nil
+}
+
+func (m *memoryCounter) Get(_ context.Context, day string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.days[day], nil
+}
+
+// isQuotaError reports whether err was raised by the quota rather than the api
+func isQuotaError(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrQuotaExceeded)
+}
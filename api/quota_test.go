@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota_Acquire(t *testing.T) {
+	now := time.Date(2022, 4, 1, 23, 59, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		opts     []QuotaOption
+		acquires int
+		advance  time.Duration
+		err      error
+		status   QuotaStatus
+	}{
+		{
+			name:     "within both windows",
+			opts:     []QuotaOption{WithPerMinute(5), WithPerDay(500)},
+			acquires: 3,
+			status:   QuotaStatus{PerMinute: 5, PerDay: 500, MinuteRemaining: 2, DayRemaining: 497},
+		},
+		{
+			name:     "minute window used and rejecting",
+			opts:     []QuotaOption{WithPerMinute(5), WithPerDay(500), WithQueue(false)},
+			acquires: 6,
+			err:      ErrRateLimited,
+			status:   QuotaStatus{PerMinute: 5, PerDay: 500, MinuteRemaining: 0, DayRemaining: 495},
+		},
+		{
+			name:     "minute window refills",
+			opts:     []QuotaOption{WithPerMinute(5), WithPerDay(500), WithQueue(false)},
+			acquires: 5,
+			advance:  24 * time.Second,
+			status:   QuotaStatus{PerMinute: 5, PerDay: 500, MinuteRemaining: 2, DayRemaining: 495},
+		},
+		{
+			name:     "daily quota used",
+			opts:     []QuotaOption{WithPerMinute(5), WithPerDay(2)},
+			acquires: 3,
+			err:      ErrQuotaExceeded,
+			status:   QuotaStatus{PerMinute: 5, PerDay: 2, MinuteRemaining: 3, DayRemaining: 0},
+		},
+		{
+			name:     "daily quota resets at midnight",
+			opts:     []QuotaOption{WithPerMinute(5), WithPerDay(2)},
+			acquires: 2,
+			advance:  2 * time.Minute,
+			status:   QuotaStatus{PerMinute: 5, PerDay: 2, MinuteRemaining: 5, DayRemaining: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := now
+			q := NewQuota(tt.opts...)
+			q.now = func() time.Time { return clock }
+			q.lastRefill = clock
+
+			var err error
+			for i := 0; i < tt.acquires && err == nil; i++ {
+				err = q.Acquire(context.Background())
+			}
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			clock = clock.Add(tt.advance)
+
+			status, err := q.Remaining(context.Background())
+			assert.NoError(t, err)
+
+			tt.status.DayResetsAt = clock.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
+
+func TestQuota_invalidLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []QuotaOption
+	}{
+		{name: "zero per minute", opts: []QuotaOption{WithPerMinute(0)}},
+		{name: "negative per minute", opts: []QuotaOption{WithPerMinute(-1)}},
+		{name: "zero per day", opts: []QuotaOption{WithPerDay(0)}},
+		{name: "negative per day", opts: []QuotaOption{WithPerDay(-5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuota(tt.opts...)
+
+			// the free plan limits are kept so requests are neither rejected nor left waiting
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			assert.NoError(t, q.Acquire(ctx))
+
+			status, err := q.Remaining(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultPerMinute, status.PerMinute)
+			assert.Equal(t, DefaultPerDay, status.PerDay)
+		})
+	}
+}
+
+func TestQuota_AcquireQueues(t *testing.T) {
+	// one token every 10ms
+	q := NewQuota(WithPerMinute(6000), WithPerDay(500))
+	q.tokens = 0
+
+	start := time.Now()
+	assert.NoError(t, q.Acquire(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+
+	// a cancelled request gives up waiting
+	q.tokens = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.True(t, errors.Is(q.Acquire(ctx), context.Canceled))
+}
+
+// stubCounter counts in memory after incr, which can fail the increment or hold it up
+type stubCounter struct {
+	memoryCounter
+	incr func(ctx context.Context) error
+}
+
+func (s *stubCounter) Incr(ctx context.Context, day string) (int, error) {
+	if err := s.incr(ctx); err != nil {
+		return 0, err
+	}
+
+	return s.memoryCounter.Incr(ctx, day)
+}
+
+func TestQuota_counter(t *testing.T) {
+	t.Run("a request the counter fails to count gives its minute token back", func(t *testing.T) {
+		q := NewQuota(WithPerMinute(5), WithCounter(&stubCounter{
+			memoryCounter: memoryCounter{days: make(map[string]int)},
+			incr:          func(context.Context) error { return errors.New("connection refused") },
+		}))
+
+		assert.Error(t, q.Acquire(context.Background()))
+
+		status, err := q.Remaining(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 5, status.MinuteRemaining)
+	})
+
+	t.Run("the quota is read while a request is being counted", func(t *testing.T) {
+		counting, release := make(chan struct{}), make(chan struct{})
+
+		q := NewQuota(WithPerMinute(5), WithCounter(&stubCounter{
+			memoryCounter: memoryCounter{days: make(map[string]int)},
+			incr: func(ctx context.Context) error {
+				close(counting)
+				<-release
+
+				return nil
+			},
+		}))
+
+		acquired := make(chan error)
+		go func() { acquired <- q.Acquire(context.Background()) }()
+
+		<-counting
+
+		status, err := q.Remaining(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 4, status.MinuteRemaining)
+
+		close(release)
+		assert.NoError(t, <-acquired)
+	})
+}
+
+func TestClient_quota(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"Time Series (Daily)": {}}`))
+	}))
+	defer srv.Close()
+
+	client := New(WithBaseURL(srv.URL), WithMaxRetries(3), WithTimeout(time.Second),
+		WithQuota(NewQuota(WithPerDay(1))))
+
+	_, err := client.GetAllPrices(context.Background(), "IBM")
+	assert.NoError(t, err)
+
+	_, err = client.GetAllPrices(context.Background(), "IBM")
+	assert.True(t, errors.Is(err, ErrQuotaExceeded), "expected %v got %v", ErrQuotaExceeded, err)
+
+	// the rejected request is neither sent nor retried
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Period is the span of time a bar of a series covers
+type Period string
+
+const (
+	Daily     Period = "daily"
+	Weekly    Period = "weekly"
+	Monthly   Period = "monthly"
+	Quarterly Period = "quarterly"
+)
+
+// periodFunctions are the api functions returning a series of the period, quarterly bars are only resampled
+var periodFunctions = map[Period]string{
+	Daily:   "TIME_SERIES_DAILY",
+	Weekly:  "TIME_SERIES_WEEKLY",
+	Monthly: "TIME_SERIES_MONTHLY",
+}
+
+// ParsePeriod returns the period named s
+func ParsePeriod(s string) (Period, error) {
+	switch period := Period(s); period {
+	case Daily, Weekly, Monthly, Quarterly:
+		return period, nil
+	default:
+		return "", fmt.Errorf("invalid interval %q: expected one of daily, weekly, monthly or quarterly", s)
+	}
+}
+
+// Upstream reports whether the api serves the series of the period
+func (p Period) Upstream() bool {
+	_, ok := periodFunctions[p]
+
+	return ok
+}
+
+// Start returns the first day of the period n periods before t, enough days for n bars ending at t
+func (p Period) Start(t time.Time, n int) time.Time {
+	switch p {
+	case Weekly:
+		return t.AddDate(0, 0, -7*n)
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, -(n - 1), 0)
+	case Quarterly:
+		quarter := time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location())
+
+		return quarter.AddDate(0, -3*(n-1), 0)
+	default:
+		return t.AddDate(0, 0, -n)
+	}
+}
+
+// key returns the key of the period a day falls in
+func (p Period) key(t time.Time) string {
+	switch p {
+	case Weekly:
+		year, week := t.ISOWeek()
+
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Monthly:
+		return t.Format("2006-01")
+	case Quarterly:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	default:
+		return t.Format(Format)
+	}
+}
+
+// Resample rolls daily prices, latest first, into bars of the period. A bar opens at the open of its first day, closes
+// at the close of its last day and is keyed by its last day, as the api does for its weekly and monthly series.
+// Its high and low are the highest high and lowest low and its volume the total volume of its days.
+func Resample(prices []*DailyPrice, period Period) ([]*DailyPrice, error) {
+	if period == Daily {
+		return prices, nil
+	}
+
+	bars := make([]*DailyPrice, 0)
+
+	var current string
+	var bar *DailyPrice
+
+	for _, price := range prices {
+		day, err := time.Parse(Format, price.Day)
+		if err != nil {
+			return nil, fmt.Errorf("converting daily time series: %w", err)
+		}
+
+		// prices are latest first so the first day seen of a period is its last
+		if key := period.key(day); key != current {
+			current = key
+			bar = &DailyPrice{
+				Day: price.Day,
+				Price: &Price{
+					High:  price.Price.High,
+					Low:   price.Price.Low,
+					Close: price.Price.Close,
+				},
+			}
+
+			bars = append(bars, bar)
+		}
+
+		bar.Price.Open = price.Price.Open
+
+		if price.Price.High.Cmp(bar.Price.High) > 0 {
+			bar.Price.High = price.Price.High
+		}
+
+		if price.Price.Low.Cmp(bar.Price.Low) < 0 {
+			bar.Price.Low = price.Price.Low
+		}
+
+		bar.Price.Volume += price.Price.Volume
+	}
+
+	return bars, nil
+}
+
+// Ordered returns the prices of the series between from and to inclusive, latest first. A zero from or to leaves
+// that end of the range open.
+func Ordered(series TimeSeriesDaily, from, to time.Time) []*DailyPrice {
+	prices := make([]*DailyPrice, 0, len(series))
+
+	for day, price := range series {
+		if !from.IsZero() && day < from.Format(Format) {
+			continue
+		}
+
+		if !to.IsZero() && day > to.Format(Format) {
+			continue
+		}
+
+		price := price
+
+		prices = append(prices, &DailyPrice{
+			Day:   day,
+			Price: &price,
+		})
+	}
+
+	// the api format sorts chronologically
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Day > prices[j].Day
+	})
+
+	return prices
+}
+
+// GetPeriodSeries gets the daily, weekly or monthly series of symbol, bars are keyed by the last trading day of
+// their period
+func (c *Client) GetPeriodSeries(ctx context.Context, symbol string, period Period) (*JSONResponse, error) {
+	function, ok := periodFunctions[period]
+	if !ok {
+		return nil, fmt.Errorf("no %s series upstream", period)
+	}
+
+	requestURL := fmt.Sprintf("%s?apikey=%s&function=%s&symbol=%s&datatype=json", c.options.baseURL, c.options.apiKey, function, url.QueryEscape(symbol))
+
+	respBody, err := c.performRequest(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = respBody.Close()
+	}()
+
+	body, err := ioutil.ReadAll(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	var res JSONResponse
+
+	if err = json.Unmarshal(body, &res.Errors); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if err = checkResponse(&res.Errors); err != nil {
+		return nil, err
+	}
+
+	// the series is keyed "Time Series (Daily)", "Weekly Time Series" or "Monthly Time Series"
+	for key, value := range fields {
+		switch {
+		case key == "Meta Data":
+			err = json.Unmarshal(value, &res.MetaData)
+		case strings.Contains(key, _timeSeriesPrefix):
+			err = json.Unmarshal(value, &res.DailyPrices)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResample(t *testing.T) {
+	// Thursday 2022-03-31 closes both the week and the month, Friday 2022-04-01 opens the next month
+	prices := []*DailyPrice{
+		{Day: "2022-04-04", Price: &Price{Open: MustParseDecimal("310.0900"), High: MustParseDecimal("315.1100"), Low: MustParseDecimal("309.7100"), Close: MustParseDecimal("314.9700"), Volume: 24289644}},
+		{Day: "2022-04-01", Price: &Price{Open: MustParseDecimal("309.3700"), High: MustParseDecimal("310.1300"), Low: MustParseDecimal("305.5400"), Close: MustParseDecimal("309.4200"), Volume: 27110529}},
+		{Day: "2022-03-31", Price: &Price{Open: MustParseDecimal("313.9000"), High: MustParseDecimal("315.1400"), Low: MustParseDecimal("307.8900"), Close: MustParseDecimal("308.3100"), Volume: 33422070}},
+		{Day: "2022-03-30", Price: &Price{Open: MustParseDecimal("313.7600"), High: MustParseDecimal("315.9500"), Low: MustParseDecimal("311.5800"), Close: MustParseDecimal("313.8600"), Volume: 28163555}},
+		{Day: "2022-03-28", Price: &Price{Open: MustParseDecimal("304.3300"), High: MustParseDecimal("310.8000"), Low: MustParseDecimal("304.3300"), Close: MustParseDecimal("310.7000"), Volume: 29578166}},
+	}
+
+	tests := []struct {
+		name     string
+		period   Period
+		expected []*DailyPrice
+	}{
+		{
+			name:     "daily prices are returned as they are",
+			period:   Daily,
+			expected: prices,
+		},
+		{
+			name:   "weekly bars",
+			period: Weekly,
+			expected: []*DailyPrice{
+				{Day: "2022-04-04", Price: &Price{Open: MustParseDecimal("310.0900"), High: MustParseDecimal("315.1100"), Low: MustParseDecimal("309.7100"), Close: MustParseDecimal("314.9700"), Volume: 24289644}},
+				{Day: "2022-04-01", Price: &Price{Open: MustParseDecimal("304.3300"), High: MustParseDecimal("315.9500"), Low: MustParseDecimal("304.3300"), Close: MustParseDecimal("309.4200"), Volume: 118274320}},
+			},
+		},
+		{
+			name:   "monthly bars",
+			period: Monthly,
+			expected: []*DailyPrice{
+				{Day: "2022-04-04", Price: &Price{Open: MustParseDecimal("309.3700"), High: MustParseDecimal("315.1100"), Low: MustParseDecimal("305.5400"), Close: MustParseDecimal("314.9700"), Volume: 51400173}},
+				{Day: "2022-03-31", Price: &Price{Open: MustParseDecimal("304.3300"), High: MustParseDecimal("315.9500"), Low: MustParseDecimal("304.3300"), Close: MustParseDecimal("308.3100"), Volume: 91163791}},
+			},
+		},
+		{
+			name:   "quarterly bars",
+			period: Quarterly,
+			expected: []*DailyPrice{
+				{Day: "2022-04-04", Price: &Price{Open: MustParseDecimal("309.3700"), High: MustParseDecimal("315.1100"), Low: MustParseDecimal("305.5400"), Close: MustParseDecimal("314.9700"), Volume: 51400173}},
+				{Day: "2022-03-31", Price: &Price{Open: MustParseDecimal("304.3300"), High: MustParseDecimal("315.9500"), Low: MustParseDecimal("304.3300"), Close: MustParseDecimal("308.3100"), Volume: 91163791}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars, err := Resample(prices, tt.period)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, bars)
+		})
+	}
+}
+
+func TestPeriod_Start(t *testing.T) {
+	now := time.Date(2022, 5, 18, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC), Weekly.Start(now, 4))
+	assert.Equal(t, time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Monthly.Start(now, 3))
+	assert.Equal(t, time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC), Quarterly.Start(now, 3))
+}
+
+func TestClient_GetPeriodSeries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "TIME_SERIES_WEEKLY", r.URL.Query().Get("function"))
+
+		_, _ = w.Write([]byte(`{
+    "Meta Data": {
+        "1. Information": "Weekly Prices (open, high, low, close) and Volumes",
+        "2. Symbol": "IBM",
+        "3. Last Refreshed": "2022-04-01",
+        "4. Time Zone": "US/Eastern"
+    },
+    "Weekly Time Series": {
+        "2022-04-01": {
+            "1. open": "130.8200",
+            "2. high": "133.0800",
+            "3. low": "129.8900",
+            "4. close": "130.1500",
+            "5. volume": "18137534"
+        }
+    }
+}`))
+	}))
+	defer srv.Close()
+
+	client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(time.Second))
+
+	res, err := client.GetPeriodSeries(context.Background(), "IBM", Weekly)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01", res.MetaData.LastRefreshed)
+	assert.Equal(t, TimeSeriesDaily{
+		"2022-04-01": {Open: MustParseDecimal("130.8200"), High: MustParseDecimal("133.0800"), Low: MustParseDecimal("129.8900"), Close: MustParseDecimal("130.1500"), Volume: 18137534},
+	}, res.DailyPrices)
+
+	_, err = client.GetPeriodSeries(context.Background(), "IBM", Quarterly)
+	assert.EqualError(t, err, "no quarterly series upstream")
+}
+
+func TestClient_GetPeriodSeries_throttled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute and 500 calls per day."}`))
+	}))
+	defer srv.Close()
+
+	client := New(WithBaseURL(srv.URL), WithMaxRetries(0), WithTimeout(time.Second))
+
+	_, err := client.GetPeriodSeries(context.Background(), "IBM", Monthly)
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
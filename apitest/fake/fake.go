@@ -0,0 +1,323 @@
+// Package fake is a fake Alpha Vantage api for local development and tests. It serves the TIME_SERIES functions the
+// service uses from deterministic generated prices, or from files, and can be told to fail the way the api does so
+// clients can be exercised end-to-end without a network or quota.
+//
+//	srv := httptest.NewServer(fake.New(fake.WithFault(fake.Note, 3)))
+//	client := api.New(api.WithBaseURL(srv.URL))
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"stock_ticker/api"
+)
+
+// Fault is a way the fake fails a request
+type Fault string
+
+const (
+	// RateLimited responds with a 429 status
+	RateLimited Fault = "429"
+	// Note responds with the "Note" body the api throttles the per minute limit with
+	Note Fault = "note"
+	// DailyLimit responds with the "Information" body the api reports an exhausted daily quota with
+	DailyLimit Fault = "daily"
+	// Slow responds after the latency of the fake
+	Slow Fault = "slow"
+	// Malformed responds with a truncated JSON body
+	Malformed Fault = "malformed"
+	// ServerError responds with a 500 status
+	ServerError Fault = "500"
+)
+
+const (
+	_noteMessage        = "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute and 500 calls per day. Please visit https://www.alphavantage.co/premium/ if you would like to target a higher API call frequency."
+	_dailyLimitMessage  = "Thank you for using Alpha Vantage! Our standard API rate limit is 500 requests per day. Please subscribe to any of the premium plans at https://www.alphavantage.co/premium/ to instantly remove all daily rate limits."
+	_invalidKeyMessage  = "the parameter apikey is invalid or missing. Please claim your free API key on (https://www.alphavantage.co/support/#api-key). It should take less than 20 seconds."
+	_invalidCallMessage = "Invalid API call. Please retry or visit the documentation (https://www.alphavantage.co/documentation/) for %s."
+
+	_defaultLatency = 5 * time.Second
+	_defaultHistory = 5 * 252
+)
+
+// ParseFault returns the fault named s
+func ParseFault(s string) (Fault, error) {
+	switch fault := Fault(s); fault {
+	case RateLimited, Note, DailyLimit, Slow, Malformed, ServerError:
+		return fault, nil
+	default:
+		return "", fmt.Errorf("invalid fault %q: expected one of 429, note, daily, slow, malformed or 500", s)
+	}
+}
+
+// scheduledFault fails every nth request
+type scheduledFault struct {
+	fault Fault
+	every int
+}
+
+// Option specifies a builder function for configuring a Server
+type Option func(*Server)
+
+// Server is a fake Alpha Vantage api. It serves TIME_SERIES_DAILY, TIME_SERIES_DAILY_ADJUSTED, TIME_SERIES_WEEKLY,
+// TIME_SERIES_MONTHLY and TIME_SERIES_INTRADAY for any symbol, the prices of a symbol being the same on every run.
+type Server struct {
+	apiKey  string
+	symbols map[string]bool
+	dataDir string
+	end     time.Time
+	history int
+	latency time.Duration
+	faults  []scheduledFault
+
+	mu       sync.Mutex
+	requests int
+	queued   []Fault
+}
+
+// New initializes a fake api serving prices up to the last weekday
+func New(opts ...Option) *Server {
+	now := time.Now().UTC()
+
+	s := &Server{
+		end:     time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		history: _defaultHistory,
+		latency: _defaultLatency,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithKey rejects requests without the api key
+func WithKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithSymbols serves the symbols only, others are rejected as the api rejects unknown symbols
+func WithSymbols(symbols ...string) Option {
+	return func(s *Server) {
+		s.symbols = make(map[string]bool, len(symbols))
+		for _, symbol := range symbols {
+			s.symbols[strings.ToUpper(symbol)] = true
+		}
+	}
+}
+
+// WithDataDir serves the responses found in dir as <FUNCTION>/<SYMBOL>.json verbatim, e.g.
+// TIME_SERIES_DAILY/IBM.json, rather than generating them
+func WithDataDir(dir string) Option {
+	return func(s *Server) {
+		s.dataDir = dir
+	}
+}
+
+// WithEnd sets the last day of the generated prices, weekends roll back to the friday before
+func WithEnd(end time.Time) Option {
+	return func(s *Server) {
+		s.end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// WithHistory sets the number of days of the full generated series
+func WithHistory(days int) Option {
+	return func(s *Server) {
+		s.history = days
+	}
+}
+
+// WithLatency sets how long slow responses take
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithFault fails every nth request with the fault, counting requests from 1
+func WithFault(fault Fault, every int) Option {
+	return func(s *Server) {
+		if every > 0 {
+			s.faults = append(s.faults, scheduledFault{fault: fault, every: every})
+		}
+	}
+}
+
+// Fail fails the next requests with the faults, one request per fault in order, before any scheduled fault
+func (s *Server) Fail(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queued = append(s.queued, faults...)
+}
+
+// Requests returns the number of requests served
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// ServeHTTP serves a request to the api
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault, ok := s.next()
+	if ok {
+		if done := s.fail(w, r, fault); done {
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	function := query.Get("function")
+	symbol := strings.ToUpper(query.Get("symbol"))
+
+	switch {
+	case s.apiKey != "" && query.Get("apikey") != s.apiKey, query.Get("apikey") == "":
+		writeJSON(w, map[string]string{"Error Message": _invalidKeyMessage})
+
+		return
+	case symbol == "" || (s.symbols != nil && !s.symbols[symbol]):
+		writeJSON(w, map[string]string{"Error Message": fmt.Sprintf(_invalidCallMessage, function)})
+
+		return
+	}
+
+	body, err := s.file(function, symbol)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if body != nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+
+		return
+	}
+
+	compact := query.Get("outputsize") != "full"
+
+	var resp interface{}
+
+	switch function {
+	case "TIME_SERIES_DAILY":
+		resp = s.daily(symbol, compact)
+	case "TIME_SERIES_DAILY_ADJUSTED":
+		resp = s.adjusted(symbol, compact)
+	case "TIME_SERIES_WEEKLY":
+		resp = s.period(symbol, api.Weekly, "Weekly")
+	case "TIME_SERIES_MONTHLY":
+		resp = s.period(symbol, api.Monthly, "Monthly")
+	case "TIME_SERIES_INTRADAY":
+		intraday, err := s.intraday(symbol, query.Get("interval"), compact)
+		if err != nil {
+			writeJSON(w, map[string]string{"Error Message": fmt.Sprintf(_invalidCallMessage, function)})
+
+			return
+		}
+
+		resp = intraday
+	default:
+		writeJSON(w, map[string]string{"Error Message": fmt.Sprintf("This API function (%s) does not exist.", function)})
+
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+// next counts the request and returns the fault it is failed with, if any
+func (s *Server) next() (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if len(s.queued) != 0 {
+		fault := s.queued[0]
+		s.queued = s.queued[1:]
+
+		return fault, true
+	}
+
+	for _, scheduled := range s.faults {
+		if s.requests%scheduled.every == 0 {
+			return scheduled.fault, true
+		}
+	}
+
+	return "", false
+}
+
+// fail fails the request with the fault and reports whether the response is written, a slow request is delayed then
+// served as usual
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, fault Fault) bool {
+	switch fault {
+	case RateLimited:
+		w.WriteHeader(http.StatusTooManyRequests)
+	case ServerError:
+		w.WriteHeader(http.StatusInternalServerError)
+	case Note:
+		writeJSON(w, map[string]string{"Note": _noteMessage})
+	case DailyLimit:
+		writeJSON(w, map[string]string{"Information": _dailyLimitMessage})
+	case Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Meta Data": {"1. Information": "Daily Prices (open, high, low, close) and Volumes", "2. Symbol": `))
+	case Slow:
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return true
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// file returns the response stored for the function and symbol, nil when none is
+func (s *Server) file(function, symbol string) ([]byte, error) {
+	if s.dataDir == "" {
+		return nil, nil
+	}
+
+	// the names come from the request so they must not reach outside the directory
+	if strings.ContainsAny(function+symbol, `/\`) || strings.Contains(function+symbol, "..") {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadFile(filepath.Join(s.dataDir, function, symbol+".json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return body, err
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,187 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+)
+
+// a friday
+var end = time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+func newClient(t *testing.T, fake *Server) api.API {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	return api.New(api.WithBaseURL(srv.URL), api.WithKey("demo"), api.WithMaxRetries(0), api.WithTimeout(time.Second))
+}
+
+func TestServer_daily(t *testing.T) {
+	client := newClient(t, New(WithEnd(end), WithHistory(300)))
+
+	compact, err := client.GetSeries(context.Background(), "IBM", api.Compact)
+	assert.NoError(t, err)
+	assert.Len(t, compact.DailyPrices, 100)
+	assert.Equal(t, "2022-04-01", compact.MetaData.LastRefreshed)
+	assert.Equal(t, "IBM", compact.MetaData.Symbol)
+
+	full, err := client.GetSeries(context.Background(), "IBM", api.Full)
+	assert.NoError(t, err)
+	assert.Len(t, full.DailyPrices, 300)
+
+	// the same symbol always gets the same prices
+	assert.Equal(t, full.DailyPrices["2022-04-01"], compact.DailyPrices["2022-04-01"])
+
+	// weekends are not trading days
+	_, ok := full.DailyPrices["2022-03-27"]
+	assert.False(t, ok)
+
+	for day, price := range full.DailyPrices {
+		assert.True(t, price.Low.Cmp(price.Open) <= 0 && price.Low.Cmp(price.Close) <= 0, day)
+		assert.True(t, price.High.Cmp(price.Open) >= 0 && price.High.Cmp(price.Close) >= 0, day)
+	}
+
+	other, err := client.GetSeries(context.Background(), "MSFT", api.Compact)
+	assert.NoError(t, err)
+	assert.NotEqual(t, compact.DailyPrices["2022-04-01"], other.DailyPrices["2022-04-01"])
+}
+
+func TestServer_series(t *testing.T) {
+	client := newClient(t, New(WithEnd(end), WithHistory(300)))
+
+	adjusted, err := client.GetAdjustedSeries(context.Background(), "IBM", api.Compact)
+	assert.NoError(t, err)
+
+	raw, actions, err := adjusted.Split()
+	assert.NoError(t, err)
+	assert.Len(t, raw.DailyPrices, 100)
+	assert.Empty(t, actions)
+
+	weekly, err := client.GetPeriodSeries(context.Background(), "IBM", api.Weekly)
+	assert.NoError(t, err)
+	assert.Len(t, weekly.DailyPrices, 60)
+
+	monthly, err := client.GetPeriodSeries(context.Background(), "IBM", api.Monthly)
+	assert.NoError(t, err)
+	assert.Contains(t, monthly.DailyPrices, "2022-03-31")
+
+	intraday, err := client.GetIntraday(context.Background(), "IBM", api.FiveMinutes, api.Compact)
+	assert.NoError(t, err)
+
+	bars, err := intraday.Bars()
+	assert.NoError(t, err)
+	assert.Len(t, bars, 100)
+	assert.Equal(t, "2022-04-01 15:55:00", intraday.MetaData.LastRefreshed)
+}
+
+func TestServer_faults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+		err   error
+	}{
+		{name: "429", fault: RateLimited, err: api.ErrRateLimited},
+		{name: "throttling note", fault: Note, err: api.ErrRateLimited},
+		{name: "daily limit", fault: DailyLimit, err: api.ErrQuotaExceeded},
+		{name: "server error", fault: ServerError, err: api.ErrUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := New(WithEnd(end), WithHistory(10))
+			client := newClient(t, fake)
+
+			fake.Fail(tt.fault)
+
+			_, err := client.GetSeries(context.Background(), "IBM", api.Compact)
+			assert.True(t, errors.Is(err, tt.err), "expected %v got %v", tt.err, err)
+
+			// only the next request fails
+			_, err = client.GetSeries(context.Background(), "IBM", api.Compact)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, fake.Requests())
+		})
+	}
+
+	t.Run("malformed json", func(t *testing.T) {
+		fake := New(WithEnd(end), WithHistory(10))
+		client := newClient(t, fake)
+
+		fake.Fail(Malformed)
+
+		_, err := client.GetSeries(context.Background(), "IBM", api.Compact)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "decoding response")
+	})
+
+	t.Run("slow response", func(t *testing.T) {
+		fake := New(WithEnd(end), WithHistory(10), WithLatency(time.Minute))
+		client := newClient(t, fake)
+
+		fake.Fail(Slow)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.GetSeries(ctx, "IBM", api.Compact)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected %v got %v", context.DeadlineExceeded, err)
+	})
+
+	t.Run("every nth request", func(t *testing.T) {
+		client := newClient(t, New(WithEnd(end), WithHistory(10), WithFault(Note, 3)))
+
+		for i := 1; i <= 6; i++ {
+			_, err := client.GetSeries(context.Background(), "IBM", api.Compact)
+			if i%3 == 0 {
+				assert.True(t, errors.Is(err, api.ErrRateLimited), "request %d: %v", i, err)
+			} else {
+				assert.NoError(t, err, "request %d", i)
+			}
+		}
+	})
+}
+
+func TestServer_errors(t *testing.T) {
+	srv := httptest.NewServer(New(WithEnd(end), WithHistory(10), WithKey("demo"), WithSymbols("IBM")))
+	defer srv.Close()
+
+	_, err := api.New(api.WithBaseURL(srv.URL), api.WithKey("wrong"), api.WithMaxRetries(0)).GetSeries(context.Background(), "IBM", api.Compact)
+	assert.True(t, errors.Is(err, api.ErrInvalidAPIKey), "expected %v got %v", api.ErrInvalidAPIKey, err)
+
+	_, err = api.New(api.WithBaseURL(srv.URL), api.WithKey("demo"), api.WithMaxRetries(0)).GetSeries(context.Background(), "MSFT", api.Compact)
+	assert.True(t, errors.Is(err, api.ErrInvalidSymbol), "expected %v got %v", api.ErrInvalidSymbol, err)
+}
+
+func TestServer_dataDir(t *testing.T) {
+	dir := t.TempDir()
+
+	body := `{"Meta Data": {"2. Symbol": "IBM", "3. Last Refreshed": "2022-04-01"}, "Time Series (Daily)": {"2022-04-01": {"1. open": "129.6600", "2. high": "130.2700", "3. low": "128.0600", "4. close": "130.1500", "5. volume": "4012373"}}}`
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "TIME_SERIES_DAILY"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "TIME_SERIES_DAILY", "IBM.json"), []byte(body), 0o644))
+
+	client := newClient(t, New(WithEnd(end), WithHistory(10), WithDataDir(dir)))
+
+	resp, err := client.GetSeries(context.Background(), "IBM", api.Compact)
+	assert.NoError(t, err)
+	assert.Equal(t, api.TimeSeriesDaily{"2022-04-01": {Open: api.MustParseDecimal("129.6600"), High: api.MustParseDecimal("130.2700"), Low: api.MustParseDecimal("128.0600"), Close: api.MustParseDecimal("130.1500"), Volume: 4012373}}, resp.DailyPrices)
+
+	// symbols without a file are generated
+	resp, err = client.GetSeries(context.Background(), "MSFT", api.Compact)
+	assert.NoError(t, err)
+	assert.Len(t, resp.DailyPrices, 10)
+
+	// the path of a file cannot leave the directory
+	w := httptest.NewRecorder()
+	New(WithDataDir(dir)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?apikey=demo&function=..&symbol=IBM", nil))
+	assert.Contains(t, w.Body.String(), "does not exist")
+}
@@ -0,0 +1,250 @@
+package fake
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"stock_ticker/api"
+)
+
+const (
+	_timeZone = "US/Eastern"
+
+	// _compactDays and _compactBars are the days and bars of a compact response
+	_compactDays = 100
+	_compactBars = 100
+	// _intradayDays is the number of days of a full intraday response
+	_intradayDays = 20
+)
+
+// periodResponse is the response of TIME_SERIES_WEEKLY and TIME_SERIES_MONTHLY, their series are keyed
+// "Weekly Time Series" and "Monthly Time Series"
+type periodResponse map[string]interface{}
+
+// intradayResponse is the response of TIME_SERIES_INTRADAY, its series is keyed by its interval
+type intradayResponse map[string]interface{}
+
+// prices generates the daily prices of symbol up to the end day, latest first. Each day opens near the close of the
+// day before and moves by about 1.5%, the walk being seeded by the symbol so a symbol always gets the same prices.
+func (s *Server) prices(symbol string) []*api.DailyPrice {
+	rng := rand.New(rand.NewSource(seed(symbol)))
+
+	days := tradingDays(s.end, s.history)
+	prices := make([]*api.DailyPrice, len(days))
+
+	closing := 20 + rng.Float64()*280
+
+	// walk from the earliest day so later days follow from earlier ones
+	for i := len(days) - 1; i >= 0; i-- {
+		open := closing * (1 + rng.NormFloat64()*0.005)
+		closing = math.Max(1, open*(1+rng.NormFloat64()*0.015))
+		high := math.Max(open, closing) * (1 + math.Abs(rng.NormFloat64())*0.005)
+		low := math.Min(open, closing) * (1 - math.Abs(rng.NormFloat64())*0.005)
+
+		prices[i] = &api.DailyPrice{
+			Day: days[i].Format(api.Format),
+			Price: &api.Price{
+				Open:   decimal(open),
+				High:   decimal(high),
+				Low:    decimal(low),
+				Close:  decimal(closing),
+				Volume: 1000000 + rng.Int63n(9000000),
+			},
+		}
+	}
+
+	return prices
+}
+
+func (s *Server) daily(symbol string, compact bool) *api.JSONResponse {
+	prices := s.prices(symbol)
+
+	md := metaData(symbol, "Daily Prices (open, high, low, close) and Volumes", prices)
+	if compact {
+		md.OutputSize = "Compact"
+		prices = latest(prices, _compactDays)
+	}
+
+	resp := &api.JSONResponse{
+		MetaData:    md,
+		DailyPrices: make(api.TimeSeriesDaily, len(prices)),
+	}
+
+	for _, price := range prices {
+		resp.DailyPrices[price.Day] = *price.Price
+	}
+
+	return resp
+}
+
+// adjusted responds with no dividends nor splits so adjusted prices are the raw prices
+func (s *Server) adjusted(symbol string, compact bool) *api.AdjustedResponse {
+	prices := s.prices(symbol)
+
+	md := metaData(symbol, "Daily Time Series with Splits and Dividend Events", prices)
+	if compact {
+		md.OutputSize = "Compact"
+		prices = latest(prices, _compactDays)
+	}
+
+	resp := &api.AdjustedResponse{
+		MetaData:    md,
+		DailyPrices: make(api.TimeSeriesDailyAdjusted, len(prices)),
+	}
+
+	for _, price := range prices {
+		resp.DailyPrices[price.Day] = api.AdjustedPrice{
+			Open:             price.Price.Open,
+			High:             price.Price.High,
+			Low:              price.Price.Low,
+			Close:            price.Price.Close,
+			AdjustedClose:    price.Price.Close,
+			Volume:           price.Price.Volume,
+			DividendAmount:   api.NewDecimal(0, 4),
+			SplitCoefficient: api.NewDecimal(10, 1),
+		}
+	}
+
+	return resp
+}
+
+// period responds with the bars of the daily prices of the period, name being how the api titles it
+func (s *Server) period(symbol string, period api.Period, name string) periodResponse {
+	prices := s.prices(symbol)
+
+	md := metaData(symbol, name+" Prices (open, high, low, close) and Volumes", prices)
+	md.OutputSize = ""
+
+	// the prices are generated so they always parse
+	bars, _ := api.Resample(prices, period)
+
+	series := make(api.TimeSeriesDaily, len(bars))
+	for _, bar := range bars {
+		series[bar.Day] = *bar.Price
+	}
+
+	return periodResponse{
+		"Meta Data":           md,
+		name + " Time Series": series,
+	}
+}
+
+// intraday responds with bars of the interval over the regular session of the latest days. Each day starts from the
+// open of its daily price.
+func (s *Server) intraday(symbol, name string, compact bool) (intradayResponse, error) {
+	interval, err := api.ParseInterval(name)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(_timeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	rng := rand.New(rand.NewSource(seed(symbol + string(interval))))
+
+	days := latest(s.prices(symbol), _intradayDays)
+	series := make(map[string]api.Price)
+
+	var timestamps []string
+
+	for i := len(days) - 1; i >= 0; i-- {
+		day, _ := time.ParseInLocation(api.Format, days[i].Day, loc)
+
+		price := days[i].Price.Open.Float64()
+
+		for t := day.Add(9*time.Hour + 30*time.Minute); t.Before(day.Add(16 * time.Hour)); t = t.Add(interval.Duration()) {
+			open := price
+			price = math.Max(1, open*(1+rng.NormFloat64()*0.001))
+
+			series[t.Format(api.IntradayFormat)] = api.Price{
+				Open:   decimal(open),
+				High:   decimal(math.Max(open, price) * (1 + math.Abs(rng.NormFloat64())*0.0005)),
+				Low:    decimal(math.Min(open, price) * (1 - math.Abs(rng.NormFloat64())*0.0005)),
+				Close:  decimal(price),
+				Volume: 1000 + rng.Int63n(50000),
+			}
+
+			timestamps = append(timestamps, t.Format(api.IntradayFormat))
+		}
+	}
+
+	outputSize := "Full size"
+
+	if compact {
+		outputSize = "Compact"
+
+		for len(timestamps) > _compactBars {
+			delete(series, timestamps[0])
+			timestamps = timestamps[1:]
+		}
+	}
+
+	var lastRefreshed string
+	if len(timestamps) != 0 {
+		lastRefreshed = timestamps[len(timestamps)-1]
+	}
+
+	return intradayResponse{
+		"Meta Data": api.IntradayMD{
+			Information:   "Intraday (" + string(interval) + ") open, high, low, close prices and volume",
+			Symbol:        symbol,
+			LastRefreshed: lastRefreshed,
+			Interval:      string(interval),
+			OutputSize:    outputSize,
+			TimeZone:      _timeZone,
+		},
+		"Time Series (" + string(interval) + ")": series,
+	}, nil
+}
+
+func metaData(symbol, information string, prices []*api.DailyPrice) api.MD {
+	md := api.MD{
+		Information: information,
+		Symbol:      symbol,
+		OutputSize:  "Full size",
+		TimeZone:    _timeZone,
+	}
+
+	if len(prices) != 0 {
+		md.LastRefreshed = prices[0].Day
+	}
+
+	return md
+}
+
+// tradingDays returns the n weekdays up to end, latest first
+func tradingDays(end time.Time, n int) []time.Time {
+	days := make([]time.Time, 0, n)
+
+	for day := end; len(days) < n; day = day.AddDate(0, 0, -1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+func latest(prices []*api.DailyPrice, n int) []*api.DailyPrice {
+	if len(prices) > n {
+		return prices[:n]
+	}
+
+	return prices
+}
+
+// decimal quotes a price with 4 decimal places as the api does
+func decimal(f float64) api.Decimal {
+	return api.NewDecimal(int64(math.Round(f*10000)), 4)
+}
+
+func seed(s string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return int64(h.Sum64())
+}
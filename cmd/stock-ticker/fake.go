@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/apitest/fake"
+)
+
+const _fakeUpstream = "fake-upstream"
+
+// runFakeUpstream serves a fake Alpha Vantage api so the service can be run end-to-end against it with --base-url
+//
+//	stock-ticker fake-upstream -addr :8081 -faults note=5,slow=7
+//	stock-ticker -base-url http://localhost:8081/query
+func runFakeUpstream(args []string) {
+	flags := flag.NewFlagSet(_fakeUpstream, flag.ExitOnError)
+
+	addr := flags.String("addr", ":8081", "address to listen on")
+	dataDir := flags.String("data-dir", "", "directory of responses served as <FUNCTION>/<SYMBOL>.json, generated when missing")
+	end := flags.String("end", "", "last day of the generated prices as YYYY-MM-DD, today by default")
+	history := flags.Int("history", 5*252, "days of the full generated series")
+	latency := flags.Duration("latency", 5*time.Second, "how long slow responses take")
+	key := flags.String("key", "", "api key requests must carry, any by default")
+	symbols := flags.String("symbols", "", "comma separated symbols served, any by default")
+	faults := flags.String("faults", "", "faults and every how many requests they happen, e.g. 429=5,note=7,daily=50,slow=3,malformed=11,500=13")
+
+	_ = flags.Parse(args)
+
+	opts := []fake.Option{
+		fake.WithDataDir(*dataDir),
+		fake.WithHistory(*history),
+		fake.WithLatency(*latency),
+		fake.WithKey(*key),
+	}
+
+	if *end != "" {
+		day, err := time.Parse("2006-01-02", *end)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid end")
+		}
+
+		opts = append(opts, fake.WithEnd(day))
+	}
+
+	if *symbols != "" {
+		opts = append(opts, fake.WithSymbols(strings.Split(*symbols, ",")...))
+	}
+
+	faultOpts, err := parseFaults(*faults)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid faults")
+	}
+
+	srv := &http.Server{
+		Handler:      fake.New(append(opts, faultOpts...)...),
+		Addr:         *addr,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: *latency + 10*time.Second,
+	}
+
+	log.Info().
+		Str("app", _appName).
+		Str("addr", *addr).
+		Msg("starting fake upstream")
+
+	if err := srv.ListenAndServe(); err != nil {
+		log.Fatal().Err(err).Msg("fake upstream")
+	}
+}
+
+// parseFaults parses a comma separated list of fault=every pairs
+func parseFaults(spec string) ([]fake.Option, error) {
+	var opts []fake.Option
+
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid fault %q: expected fault=every", pair)
+		}
+
+		fault, err := fake.ParseFault(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		every, err := strconv.Atoi(parts[1])
+		if err != nil || every < 1 {
+			return nil, fmt.Errorf("invalid fault %q: expected a number of requests", pair)
+		}
+
+		opts = append(opts, fake.WithFault(fault, every))
+	}
+
+	return opts, nil
+}
@@ -7,9 +7,11 @@ require (
 	github.com/gomodule/redigo v1.8.8
 	github.com/hashicorp/go-retryablehttp v0.7.0
 	github.com/justinas/alice v1.2.0
+	github.com/lib/pq v1.10.9
 	github.com/nitishm/go-rejson/v4 v4.1.0
 	github.com/rs/zerolog v1.26.1
 	github.com/stretchr/testify v1.7.1
+	go.etcd.io/bbolt v1.3.6
 )
 
 require (
@@ -0,0 +1,164 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nitishm/go-rejson/v4"
+
+	"stock_ticker/api"
+	"stock_ticker/storage"
+)
+
+const (
+	_benchSymbol = "BENCH"
+	_benchDays   = 10
+)
+
+// seedBenchPrices stores a sparse series that stopped trading ten years ago, the worst case for probing day keys. It
+// returns the storage along with a handler reading redis directly.
+func seedBenchPrices(b *testing.B) (*storage.Redis, *rejson.Handler) {
+	// run docker-compose up redis so that localhost version of redis is up
+	reJsonHandler := rejson.NewReJSONHandler()
+
+	// Redigo Client
+	conn, err := redis.Dial("tcp", "localhost:6379", redis.DialPassword(""))
+	if err != nil {
+		b.Fatalf("test error :%e", err)
+	}
+
+	reJsonHandler.SetRedigoClient(conn)
+
+	r := &storage.Redis{
+		Pool: storage.NewPool("localhost:6379", ""),
+	}
+
+	daily := api.TimeSeriesDaily{}
+	last := time.Now().AddDate(-10, 0, 0)
+
+	for i := 0; i < 100; i++ {
+		daily[last.AddDate(0, 0, -i).Format(api.Format)] = api.Price{
+			Open:   api.MustParseDecimal("129.6600"),
+			High:   api.MustParseDecimal("130.2700"),
+			Low:    api.MustParseDecimal("128.0600"),
+			Close:  api.MustParseDecimal("130.1500"),
+			Volume: 4012373,
+		}
+	}
+
+	if err = r.AddPrices(context.Background(), _benchSymbol, &api.JSONResponse{DailyPrices: daily}); err != nil {
+		b.Fatalf("add prices :%e", err)
+	}
+
+	return r, reJsonHandler
+}
+
+func BenchmarkRedis_GetPriceInfo(b *testing.B) {
+	r, _ := seedBenchPrices(b)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		prices, _, err := r.GetPriceInfo(context.Background(), _benchSymbol, _benchDays)
+		if err != nil || len(prices) != _benchDays {
+			b.Fatalf("get price info: %d prices, %v", len(prices), err)
+		}
+	}
+}
+
+// BenchmarkRedis_GetPriceInfoProbing measures the previous lookup that probed one key per calendar day
+// walking backwards from today
+func BenchmarkRedis_GetPriceInfoProbing(b *testing.B) {
+	_, rh := seedBenchPrices(b)
+
+	maxDays := int(time.Now().Sub(time.Now().AddDate(-20, 0, 0)).Hours() / 24)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var counter int
+
+		for d := 1; d <= maxDays && counter < _benchDays; d++ {
+			key := storage.PriceKey(_benchSymbol, time.Now().AddDate(0, 0, -d).Format(api.Format))
+
+			value, _ := redis.Bytes(rh.JSONGet(key, "."))
+			if value == nil {
+				continue
+			}
+
+			price := api.Price{}
+			if err := json.Unmarshal(value, &price); err != nil {
+				b.Fatalf("unmarshal price :%e", err)
+			}
+
+			counter++
+		}
+
+		if counter != _benchDays {
+			b.Fatalf("probed %d prices", counter)
+		}
+	}
+}
+
+// _historyDays is about 20 years of trading days, the full history loaded on start up
+const _historyDays = 20 * 252
+
+// historyPrices returns _historyDays of prices with the given volume so loads can be told apart
+func historyPrices(volume int64) api.TimeSeriesDaily {
+	daily := make(api.TimeSeriesDaily, _historyDays)
+	first := time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < _historyDays; i++ {
+		daily[first.AddDate(0, 0, i).Format(api.Format)] = api.Price{
+			Open:   api.MustParseDecimal("129.6600"),
+			High:   api.MustParseDecimal("130.2700"),
+			Low:    api.MustParseDecimal("128.0600"),
+			Close:  api.MustParseDecimal("130.1500"),
+			Volume: volume,
+		}
+	}
+
+	return daily
+}
+
+func BenchmarkRedis_AddPrices(b *testing.B) {
+	// run docker-compose up redis so that localhost version of redis is up
+	prices := &api.JSONResponse{DailyPrices: historyPrices(4012373)}
+
+	for _, batchSize := range []int{100, storage.DefaultBatchSize, _historyDays} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			r := &storage.Redis{
+				Pool:      storage.NewPool("localhost:6379", ""),
+				BatchSize: batchSize,
+			}
+			defer r.Pool.Close()
+
+			for i := 0; i < b.N; i++ {
+				if err := r.AddPrices(context.Background(), _benchSymbol, prices); err != nil {
+					b.Fatalf("add prices: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRedis_AddPricesSequential measures the previous load that set one day per round trip
+func BenchmarkRedis_AddPricesSequential(b *testing.B) {
+	_, rh := seedBenchPrices(b)
+
+	daily := historyPrices(4012373)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for date, price := range daily {
+			if _, err := rh.JSONSet(storage.PriceKey(_benchSymbol, date), ".", price); err != nil {
+				b.Fatalf("set price: %v", err)
+			}
+		}
+	}
+}
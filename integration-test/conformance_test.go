@@ -0,0 +1,18 @@
+package integration_test
+
+import (
+	"testing"
+
+	"stock_ticker/storage"
+	"stock_ticker/storage/storagetest"
+)
+
+func TestRedis_Conformance(t *testing.T) {
+	// run docker-compose up redis so that localhost version of redis is up
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return &storage.Redis{
+			Pool:              storage.NewPool("localhost:6379", ""),
+			IntradayRetention: storagetest.IntradayRetention,
+		}
+	})
+}
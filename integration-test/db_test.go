@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/gomodule/redigo/redis"
 	"github.com/nitishm/go-rejson/v4"
@@ -8,10 +9,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"stock_ticker/api"
 	"stock_ticker/storage"
-	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
+const _testSymbol = "TEST"
+
 func TestRedis_AddPrices(t *testing.T) {
 
 	// run docker-compose up redis so that localhost version of redis is up
@@ -43,26 +47,26 @@ func TestRedis_AddPrices(t *testing.T) {
 					TimeZone:      "US/Eastern",
 				},
 				DailyPrices: map[string]api.Price{
-					"Test - 2022-04-01": {
-						Open:   "309.3700",
-						High:   "310.1300",
-						Low:    "305.5400",
-						Close:  "309.4200",
-						Volume: "27110529",
+					"2022-04-01": {
+						Open:   api.MustParseDecimal("309.3700"),
+						High:   api.MustParseDecimal("310.1300"),
+						Low:    api.MustParseDecimal("305.5400"),
+						Close:  api.MustParseDecimal("309.4200"),
+						Volume: 27110529,
 					},
-					"Test - 2022-03-31": {
-						Open:   "313.9000",
-						High:   "315.1400",
-						Low:    "307.8900",
-						Close:  "308.3100",
-						Volume: "33422070",
+					"2022-03-31": {
+						Open:   api.MustParseDecimal("313.9000"),
+						High:   api.MustParseDecimal("315.1400"),
+						Low:    api.MustParseDecimal("307.8900"),
+						Close:  api.MustParseDecimal("308.3100"),
+						Volume: 33422070,
 					},
-					"Test - 2022-03-30": {
-						Open:   "313.7600",
-						High:   "315.9500",
-						Low:    "311.5800",
-						Close:  "313.8600",
-						Volume: "28163555",
+					"2022-03-30": {
+						Open:   api.MustParseDecimal("313.7600"),
+						High:   api.MustParseDecimal("315.9500"),
+						Low:    api.MustParseDecimal("311.5800"),
+						Close:  api.MustParseDecimal("313.8600"),
+						Volume: 28163555,
 					},
 				},
 			},
@@ -73,38 +77,38 @@ func TestRedis_AddPrices(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &storage.Redis{
-				Rh: tt.rh,
+				Pool: storage.NewPool("localhost:6379", ""),
 			}
 
-			if err := r.AddPrices(tt.prices); err != nil {
+			if err := r.AddPrices(context.Background(), _testSymbol, tt.prices); err != nil {
 				assert.Equal(t, err.Error(), tt.err)
 
 				return
 			}
 
 			assert.Equal(t, &api.Price{
-				Open:   "309.3700",
-				High:   "310.1300",
-				Low:    "305.5400",
-				Close:  "309.4200",
-				Volume: "27110529",
-			}, getSpecificPrice(t, tt.rh, "Test - 2022-04-01"))
+				Open:   api.MustParseDecimal("309.3700"),
+				High:   api.MustParseDecimal("310.1300"),
+				Low:    api.MustParseDecimal("305.5400"),
+				Close:  api.MustParseDecimal("309.4200"),
+				Volume: 27110529,
+			}, getSpecificPrice(t, tt.rh, storage.PriceKey(_testSymbol, "2022-04-01")))
 
 			assert.Equal(t, &api.Price{
-				Open:   "313.9000",
-				High:   "315.1400",
-				Low:    "307.8900",
-				Close:  "308.3100",
-				Volume: "33422070",
-			}, getSpecificPrice(t, tt.rh, "Test - 2022-03-31"))
+				Open:   api.MustParseDecimal("313.9000"),
+				High:   api.MustParseDecimal("315.1400"),
+				Low:    api.MustParseDecimal("307.8900"),
+				Close:  api.MustParseDecimal("308.3100"),
+				Volume: 33422070,
+			}, getSpecificPrice(t, tt.rh, storage.PriceKey(_testSymbol, "2022-03-31")))
 
 			assert.Equal(t, &api.Price{
-				Open:   "313.7600",
-				High:   "315.9500",
-				Low:    "311.5800",
-				Close:  "313.8600",
-				Volume: "28163555",
-			}, getSpecificPrice(t, tt.rh, "Test - 2022-03-30"))
+				Open:   api.MustParseDecimal("313.7600"),
+				High:   api.MustParseDecimal("315.9500"),
+				Low:    api.MustParseDecimal("311.5800"),
+				Close:  api.MustParseDecimal("313.8600"),
+				Volume: 28163555,
+			}, getSpecificPrice(t, tt.rh, storage.PriceKey(_testSymbol, "2022-03-30")))
 
 		})
 
@@ -140,31 +144,31 @@ func TestRedis_GetPriceInfo(t *testing.T) {
 				{
 					Day: "2022-04-01",
 					Price: &api.Price{
-						Open:   "309.3700",
-						High:   "310.1300",
-						Low:    "305.5400",
-						Close:  "309.4200",
-						Volume: "27110529",
+						Open:   api.MustParseDecimal("309.3700"),
+						High:   api.MustParseDecimal("310.1300"),
+						Low:    api.MustParseDecimal("305.5400"),
+						Close:  api.MustParseDecimal("309.4200"),
+						Volume: 27110529,
 					},
 				},
 				{
 					Day: "2022-03-31",
 					Price: &api.Price{
-						Open:   "313.9000",
-						High:   "315.1400",
-						Low:    "307.8900",
-						Close:  "308.3100",
-						Volume: "33422070",
+						Open:   api.MustParseDecimal("313.9000"),
+						High:   api.MustParseDecimal("315.1400"),
+						Low:    api.MustParseDecimal("307.8900"),
+						Close:  api.MustParseDecimal("308.3100"),
+						Volume: 33422070,
 					},
 				},
 				{
 					Day: "2022-03-30",
 					Price: &api.Price{
-						Open:   "313.7600",
-						High:   "315.9500",
-						Low:    "311.5800",
-						Close:  "313.8600",
-						Volume: "28163555",
+						Open:   api.MustParseDecimal("313.7600"),
+						High:   api.MustParseDecimal("315.9500"),
+						Low:    api.MustParseDecimal("311.5800"),
+						Close:  api.MustParseDecimal("313.8600"),
+						Volume: 28163555,
 					},
 				},
 			},
@@ -175,45 +179,107 @@ func TestRedis_GetPriceInfo(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &storage.Redis{
-				Rh: tt.rh,
+				Pool: storage.NewPool("localhost:6379", ""),
 			}
 
 			// insert price data for the getPrice data to retrieve
+			daily := api.TimeSeriesDaily{}
 			for _, price := range tt.prices {
-				res, err := r.Rh.JSONSet(price.Day, ".", price.Price)
-				if err != nil {
-					t.Errorf("set price :%e", err)
-					t.FailNow()
-				}
+				daily[price.Day] = *price.Price
+			}
 
-				if res.(string) == "OK" {
-					log.Debug().Msg("success")
-				} else {
-					log.Error().Err(err).Msg("add stock prices")
-				}
+			if err := r.AddPrices(context.Background(), _testSymbol, &api.JSONResponse{DailyPrices: daily}); err != nil {
+				t.Errorf("add prices :%e", err)
+				t.FailNow()
 			}
 
-			var totClose float64
-			for _, price := range tt.prices {
-				res := getSpecificPrice(t, r.Rh, price.Day)
-				closePrice, err := strconv.ParseFloat(res.Close, 64)
+			prices, avgClose, err := r.GetPriceInfo(context.Background(), _testSymbol, tt.days)
+			if err != nil {
+				assert.Equal(t, err.Error(), tt.err)
 
-				if err != nil {
-					log.Error().Err(err).Str("close price", res.Close)
-					t.FailNow()
-				}
+				return
+			}
 
-				totClose += closePrice
+			assert.Equal(t, tt.prices, prices)
+			assert.Equal(t, tt.avgClose, avgClose)
 
-				assert.Equal(t, price.Price, res)
-			}
+			// the oldest day on its own
+			from, _ := time.Parse(api.Format, "2022-03-30")
+			to, _ := time.Parse(api.Format, "2022-03-30")
 
-			assert.Equal(t, tt.avgClose, api.FixedPrecision(totClose/float64(len(tt.prices)), 2))
+			prices, avgClose, err = r.GetPriceRange(context.Background(), _testSymbol, from, to, 0)
+			if err != nil {
+				t.Errorf("get price range :%e", err)
+				t.FailNow()
+			}
 
+			assert.Equal(t, tt.prices[2:], prices)
+			assert.Equal(t, 313.86, avgClose)
 		})
 	}
 }
 
+func TestRedis_MigrateBareKeys(t *testing.T) {
+
+	// run docker-compose up redis so that localhost version of redis is up
+	reJsonHandler := rejson.NewReJSONHandler()
+
+	// Redigo Client
+	conn, err := redis.Dial("tcp", "localhost:6379", redis.DialPassword(""))
+	if err != nil {
+		t.Fatalf("test error :%e", err)
+	}
+
+	reJsonHandler.SetRedigoClient(conn)
+
+	r := &storage.Redis{
+		Pool: storage.NewPool("localhost:6379", ""),
+	}
+
+	price := &api.Price{
+		Open:   api.MustParseDecimal("129.6600"),
+		High:   api.MustParseDecimal("130.2700"),
+		Low:    api.MustParseDecimal("128.0600"),
+		Close:  api.MustParseDecimal("130.1500"),
+		Volume: 4012373,
+	}
+
+	// a bare date key as written by older versions of the service
+	if _, err = reJsonHandler.JSONSet("1999-01-04", ".", price); err != nil {
+		t.Errorf("set price :%e", err)
+		t.FailNow()
+	}
+
+	// a key matching the bare key pattern that is no date
+	if _, err = conn.Do("SET", "2022-13-45", "not a price"); err != nil {
+		t.Fatalf("set key :%e", err)
+	}
+
+	migrated, err := r.MigrateBareKeys(context.Background(), "migrate")
+	if err != nil {
+		t.Errorf("migrate bare keys :%e", err)
+		t.FailNow()
+	}
+
+	assert.GreaterOrEqual(t, migrated, 1)
+	assert.Equal(t, price, getSpecificPrice(t, reJsonHandler, storage.PriceKey("MIGRATE", "1999-01-04")))
+
+	exists, err := redis.Bool(conn.Do("EXISTS", "1999-01-04"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the key that is no date is neither moved nor indexed
+	exists, err = redis.Bool(conn.Do("EXISTS", "2022-13-45"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = redis.Int(conn.Do("ZSCORE", storage.IndexKey("MIGRATE"), "2022-13-45"))
+	assert.ErrorIs(t, err, redis.ErrNil)
+
+	_, err = conn.Do("DEL", "2022-13-45")
+	assert.NoError(t, err)
+}
+
 func getSpecificPrice(t *testing.T, rh *rejson.Handler, key string) *api.Price {
 	value, err := redis.Bytes(rh.JSONGet(key, "."))
 	if err != nil {
@@ -234,3 +300,141 @@ func getSpecificPrice(t *testing.T, rh *rejson.Handler, key string) *api.Price {
 	return &price
 
 }
+
+func TestRedis_CorporateActions(t *testing.T) {
+
+	// run docker-compose up redis so that localhost version of redis is up
+	reJsonHandler := rejson.NewReJSONHandler()
+
+	// Redigo Client
+	conn, err := redis.Dial("tcp", "localhost:6379", redis.DialPassword(""))
+	if err != nil {
+		t.Fatalf("test error :%e", err)
+	}
+
+	reJsonHandler.SetRedigoClient(conn)
+
+	r := &storage.Redis{
+		Pool: storage.NewPool("localhost:6379", ""),
+	}
+
+	if _, err = conn.Do("DEL", storage.ActionsKey(_testSymbol)); err != nil {
+		t.Errorf("delete actions :%e", err)
+		t.FailNow()
+	}
+
+	split := api.CorporateAction{SplitCoefficient: api.MustParseDecimal("4.0"), Factor: 0.25}
+	dividend := api.CorporateAction{DividendAmount: api.MustParseDecimal("0.8200"), Factor: 0.9982}
+
+	assert.NoError(t, r.AddCorporateActions(context.Background(), _testSymbol, api.CorporateActions{"2020-08-07": dividend}))
+	assert.NoError(t, r.AddCorporateActions(context.Background(), _testSymbol, api.CorporateActions{"2020-08-31": split}))
+
+	actions, err := r.GetCorporateActions(context.Background(), _testSymbol, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, api.CorporateActions{"2020-08-07": dividend, "2020-08-31": split}, actions)
+
+	from, _ := time.Parse(api.Format, "2020-08-10")
+
+	actions, err = r.GetCorporateActions(context.Background(), _testSymbol, from)
+	assert.NoError(t, err)
+	assert.Equal(t, api.CorporateActions{"2020-08-31": split}, actions)
+}
+
+func TestRedis_Bars(t *testing.T) {
+
+	// run docker-compose up redis so that localhost version of redis is up
+	reJsonHandler := rejson.NewReJSONHandler()
+
+	// Redigo Client
+	conn, err := redis.Dial("tcp", "localhost:6379", redis.DialPassword(""))
+	if err != nil {
+		t.Fatalf("test error :%e", err)
+	}
+
+	reJsonHandler.SetRedigoClient(conn)
+
+	r := &storage.Redis{
+		Pool:              storage.NewPool("localhost:6379", ""),
+		IntradayRetention: time.Hour,
+	}
+
+	latest := time.Date(2022, 4, 1, 20, 0, 0, 0, time.UTC)
+
+	bars := []*api.IntradayBar{
+		{Timestamp: latest, Price: &api.Price{Open: api.MustParseDecimal("130.2000"), High: api.MustParseDecimal("130.2000"), Low: api.MustParseDecimal("130.1000"), Close: api.MustParseDecimal("130.1000"), Volume: 310}},
+		{Timestamp: latest.Add(-time.Hour), Price: &api.Price{Open: api.MustParseDecimal("130.1500"), High: api.MustParseDecimal("130.1500"), Low: api.MustParseDecimal("130.1500"), Close: api.MustParseDecimal("130.1500"), Volume: 200}},
+		// outside of the retention window
+		{Timestamp: latest.Add(-2 * time.Hour), Price: &api.Price{Open: api.MustParseDecimal("129.9000"), High: api.MustParseDecimal("129.9000"), Low: api.MustParseDecimal("129.9000"), Close: api.MustParseDecimal("129.9000"), Volume: 100}},
+	}
+
+	assert.NoError(t, r.AddBars(context.Background(), _testSymbol, api.OneMinute, bars))
+
+	cached, refreshed, err := r.GetBars(context.Background(), _testSymbol, api.OneMinute, 10)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), refreshed, time.Minute)
+
+	assert.Len(t, cached, 2)
+	assert.True(t, latest.Equal(cached[0].Timestamp))
+	assert.Equal(t, bars[0].Price, cached[0].Price)
+	assert.True(t, latest.Add(-time.Hour).Equal(cached[1].Timestamp))
+
+	cached, _, err = r.GetBars(context.Background(), _testSymbol, api.OneMinute, 1)
+	assert.NoError(t, err)
+	assert.Len(t, cached, 1)
+}
+
+func TestRedis_AddPricesAtomic(t *testing.T) {
+	// run docker-compose up redis so that localhost version of redis is up
+	r := &storage.Redis{
+		Pool:      storage.NewPool("localhost:6379", ""),
+		BatchSize: 100,
+	}
+	defer r.Pool.Close()
+
+	const symbol = "ATOMIC"
+
+	if !assert.NoError(t, r.AddPrices(context.Background(), symbol, &api.JSONResponse{DailyPrices: historyPrices(0)})) {
+		return
+	}
+
+	var (
+		stop = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			prices, _, err := r.GetPriceInfo(context.Background(), symbol, 0)
+			if !assert.NoError(t, err) || !assert.Len(t, prices, _historyDays) {
+				return
+			}
+
+			// a reader sees a whole load, never the days of one load mixed with those of another
+			for _, price := range prices {
+				if price.Price.Volume != prices[0].Price.Volume {
+					t.Errorf("%s has volume %d, %s %d", price.Day, price.Price.Volume, prices[0].Day,
+						prices[0].Price.Volume)
+
+					return
+				}
+			}
+		}
+	}()
+
+	for volume := int64(1); volume <= 10; volume++ {
+		assert.NoError(t, r.AddPrices(context.Background(), symbol, &api.JSONResponse{DailyPrices: historyPrices(volume)}))
+	}
+
+	close(stop)
+	wg.Wait()
+}
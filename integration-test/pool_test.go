@@ -0,0 +1,129 @@
+package integration_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/storage"
+)
+
+// TestRedis_Reconnect keeps requests flowing while redis drops every client connection, then checks the requests
+// made once it is back are all served. Setting REDIS_RESTART_COMMAND, e.g. "docker-compose restart redis", restarts
+// redis itself as well.
+func TestRedis_Reconnect(t *testing.T) {
+	tests := []struct {
+		name string
+		skip bool
+		kill func(t *testing.T)
+	}{
+		{
+			name: "connections killed",
+			kill: func(t *testing.T) {
+				// run docker-compose up redis so that localhost version of redis is up
+				conn, err := redis.Dial("tcp", "localhost:6379", redis.DialPassword(""))
+				if err != nil {
+					t.Fatalf("dial: %v", err)
+				}
+
+				defer conn.Close()
+
+				_, err = conn.Do("CLIENT", "KILL", "TYPE", "normal", "SKIPME", "yes")
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "redis restarted",
+			skip: os.Getenv("REDIS_RESTART_COMMAND") == "",
+			kill: func(t *testing.T) {
+				out, err := exec.Command("sh", "-c", os.Getenv("REDIS_RESTART_COMMAND")).CombinedOutput()
+				assert.NoError(t, err, string(out))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.skip {
+				t.Skip("REDIS_RESTART_COMMAND not set")
+			}
+
+			r := &storage.Redis{
+				Pool: storage.NewPool("localhost:6379", "", storage.WithPoolSize(4, 8)),
+			}
+			defer r.Pool.Close()
+
+			prices := &api.JSONResponse{
+				MetaData: api.MD{LastRefreshed: "2022-04-01"},
+				DailyPrices: api.TimeSeriesDaily{
+					"2022-04-01": {
+						Open:   api.MustParseDecimal("309.3700"),
+						High:   api.MustParseDecimal("310.1300"),
+						Low:    api.MustParseDecimal("305.5400"),
+						Close:  api.MustParseDecimal("309.4200"),
+						Volume: 27110529,
+					},
+				},
+			}
+
+			var (
+				recovered int32
+				stop      = make(chan struct{})
+				wg        sync.WaitGroup
+			)
+
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+
+						after := atomic.LoadInt32(&recovered) == 1
+
+						err := r.AddPrices(context.Background(), _testSymbol, prices)
+						if err == nil {
+							_, _, err = r.GetPriceInfo(context.Background(), _testSymbol, 1)
+						}
+
+						if err != nil && after {
+							t.Errorf("request after redis came back failed: %v", err)
+						}
+					}
+				}()
+			}
+
+			defer func() {
+				close(stop)
+				wg.Wait()
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+			tt.kill(t)
+
+			// wait for redis to be back before counting failures
+			if !assert.Eventually(t, func() bool {
+				return r.Ping(context.Background()) == nil
+			}, 30*time.Second, 100*time.Millisecond) {
+				return
+			}
+
+			atomic.StoreInt32(&recovered, 1)
+			time.Sleep(200 * time.Millisecond)
+		})
+	}
+}
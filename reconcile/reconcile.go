@@ -0,0 +1,307 @@
+// Package reconcile cross-checks the daily prices of the tracked symbols between two providers and reports the days
+// they disagree on.
+package reconcile
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/api"
+)
+
+const (
+	// DefaultPriceTolerance is the relative difference prices may have before they are flagged, 0.5%
+	DefaultPriceTolerance = 0.005
+	// DefaultVolumeTolerance is the relative difference volumes may have before they are flagged, 10%. Providers
+	// count volume across different sets of venues so it is compared loosely.
+	DefaultVolumeTolerance = 0.1
+)
+
+// Discrepancy is a field of a day the providers disagree on beyond the tolerance
+type Discrepancy struct {
+	Day       string  `json:"day"`
+	Field     string  `json:"field"`
+	Primary   float64 `json:"primary"`
+	Secondary float64 `json:"secondary"`
+	// Difference is relative to the primary
+	Difference float64 `json:"difference"`
+}
+
+// SymbolReport is the outcome of cross-checking a symbol
+type SymbolReport struct {
+	Symbol    string `json:"symbol"`
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	// From and To bound the days both providers have prices for
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Compared int    `json:"compared"`
+	// MissingPrimary and MissingSecondary are the days within From and To only the other provider has prices for
+	MissingPrimary   []string      `json:"missing_primary,omitempty"`
+	MissingSecondary []string      `json:"missing_secondary,omitempty"`
+	Discrepancies    []Discrepancy `json:"discrepancies,omitempty"`
+	Error            string        `json:"error,omitempty"`
+}
+
+// OK reports whether the providers agree on every day of the symbol they both have
+func (r SymbolReport) OK() bool {
+	return r.Error == "" && len(r.MissingPrimary) == 0 && len(r.MissingSecondary) == 0 && len(r.Discrepancies) == 0
+}
+
+// Report is the data quality report of the tracked symbols
+type Report struct {
+	Generated       time.Time      `json:"generated"`
+	PriceTolerance  float64        `json:"price_tolerance"`
+	VolumeTolerance float64        `json:"volume_tolerance"`
+	Symbols         []SymbolReport `json:"symbols"`
+}
+
+// Option specifies a builder function for configuring a Reconciler
+type Option func(*Reconciler)
+
+// Reconciler cross-checks the latest 100 days of the tracked symbols between the provider each symbol is read from
+// and a secondary provider. Symbols read from the secondary provider have nothing to be checked against.
+type Reconciler struct {
+	primary   *api.Providers
+	secondary api.Provider
+	symbols   []string
+
+	priceTolerance  float64
+	volumeTolerance float64
+	now             func() time.Time
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// New initializes a reconciler of symbols between their providers and secondary
+func New(primary *api.Providers, secondary api.Provider, symbols []string, opts ...Option) *Reconciler {
+	r := &Reconciler{
+		primary:         primary,
+		secondary:       secondary,
+		symbols:         symbols,
+		priceTolerance:  DefaultPriceTolerance,
+		volumeTolerance: DefaultVolumeTolerance,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithPriceTolerance sets the relative difference prices may have before they are flagged
+func WithPriceTolerance(tolerance float64) Option {
+	return func(r *Reconciler) {
+		r.priceTolerance = tolerance
+	}
+}
+
+// WithVolumeTolerance sets the relative difference volumes may have before they are flagged
+func WithVolumeTolerance(tolerance float64) Option {
+	return func(r *Reconciler) {
+		r.volumeTolerance = tolerance
+	}
+}
+
+// Reconcile cross-checks every tracked symbol in turn and keeps the report
+func (r *Reconciler) Reconcile(ctx context.Context) *Report {
+	report := &Report{
+		Generated:       r.now(),
+		PriceTolerance:  r.priceTolerance,
+		VolumeTolerance: r.volumeTolerance,
+		Symbols:         make([]SymbolReport, 0, len(r.symbols)),
+	}
+
+	for _, symbol := range r.symbols {
+		primary := r.primary.For(symbol)
+		if primary.Name() == r.secondary.Name() {
+			continue
+		}
+
+		symbolReport := r.reconcile(ctx, symbol, primary)
+
+		logger := log.Info()
+		switch {
+		case symbolReport.Error != "":
+			logger = log.Error().Str("error", symbolReport.Error)
+		case !symbolReport.OK():
+			logger = log.Warn()
+		}
+
+		logger.Str("symbol", symbol).
+			Str("primary", symbolReport.Primary).
+			Str("secondary", symbolReport.Secondary).
+			Int("compared", symbolReport.Compared).
+			Int("discrepancies", len(symbolReport.Discrepancies)).
+			Int("missing_primary", len(symbolReport.MissingPrimary)).
+			Int("missing_secondary", len(symbolReport.MissingSecondary)).
+			Msg("price reconciliation")
+
+		report.Symbols = append(report.Symbols, symbolReport)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report = report
+
+	return report
+}
+
+// Report returns the latest report, nil before the first reconciliation
+func (r *Reconciler) Report() *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.report
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, symbol string, primary api.Provider) SymbolReport {
+	report := SymbolReport{
+		Symbol:    symbol,
+		Primary:   primary.Name(),
+		Secondary: r.secondary.Name(),
+	}
+
+	primarySeries, err := primary.Daily(ctx, symbol, api.Compact)
+	if err != nil {
+		report.Error = "primary: " + err.Error()
+
+		return report
+	}
+
+	secondarySeries, err := r.secondary.Daily(ctx, symbol, api.Compact)
+	if err != nil {
+		report.Error = "secondary: " + err.Error()
+
+		return report
+	}
+
+	compared := Compare(primarySeries.Prices, secondarySeries.Prices, r.priceTolerance, r.volumeTolerance)
+	compared.Symbol, compared.Primary, compared.Secondary = report.Symbol, report.Primary, report.Secondary
+
+	return compared
+}
+
+// Compare cross-checks the days two series of prices, latest first, both cover. A volume of zero is taken as not
+// reported and is not compared.
+func Compare(primary, secondary []*api.DailyPrice, priceTolerance, volumeTolerance float64) SymbolReport {
+	var report SymbolReport
+
+	if len(primary) == 0 || len(secondary) == 0 {
+		return report
+	}
+
+	report.From = maxDay(primary[len(primary)-1].Day, secondary[len(secondary)-1].Day)
+	report.To = minDay(primary[0].Day, secondary[0].Day)
+
+	secondaryByDay := make(map[string]*api.Price, len(secondary))
+	for _, price := range secondary {
+		if price.Day >= report.From && price.Day <= report.To {
+			secondaryByDay[price.Day] = price.Price
+		}
+	}
+
+	// walk the primary earliest first so the report reads chronologically
+	for i := len(primary) - 1; i >= 0; i-- {
+		day := primary[i].Day
+		if day < report.From || day > report.To {
+			continue
+		}
+
+		other, ok := secondaryByDay[day]
+		if !ok {
+			report.MissingSecondary = append(report.MissingSecondary, day)
+
+			continue
+		}
+
+		delete(secondaryByDay, day)
+
+		report.Compared++
+		report.Discrepancies = append(report.Discrepancies, comparePrices(day, primary[i].Price, other, priceTolerance, volumeTolerance)...)
+	}
+
+	for i := len(secondary) - 1; i >= 0; i-- {
+		if _, ok := secondaryByDay[secondary[i].Day]; ok {
+			report.MissingPrimary = append(report.MissingPrimary, secondary[i].Day)
+		}
+	}
+
+	return report
+}
+
+// field is a value of a day as each provider reports it
+type field struct {
+	name               string
+	primary, secondary float64
+	tolerance          float64
+}
+
+func comparePrices(day string, primary, secondary *api.Price, priceTolerance, volumeTolerance float64) []Discrepancy {
+	var discrepancies []Discrepancy
+
+	fields := []field{
+		{"open", primary.Open.Float64(), secondary.Open.Float64(), priceTolerance},
+		{"high", primary.High.Float64(), secondary.High.Float64(), priceTolerance},
+		{"low", primary.Low.Float64(), secondary.Low.Float64(), priceTolerance},
+		{"close", primary.Close.Float64(), secondary.Close.Float64(), priceTolerance},
+	}
+
+	if primary.Volume != 0 && secondary.Volume != 0 {
+		fields = append(fields, field{"volume", float64(primary.Volume), float64(secondary.Volume), volumeTolerance})
+	}
+
+	for _, field := range fields {
+		diff := difference(field.primary, field.secondary)
+		if diff <= field.tolerance {
+			continue
+		}
+
+		discrepancies = append(discrepancies, Discrepancy{
+			Day:        day,
+			Field:      field.name,
+			Primary:    field.primary,
+			Secondary:  field.secondary,
+			Difference: api.FixedPrecision(diff, 6),
+		})
+	}
+
+	return discrepancies
+}
+
+// difference returns the difference of secondary relative to primary
+func difference(primary, secondary float64) float64 {
+	if primary == 0 {
+		if secondary == 0 {
+			return 0
+		}
+
+		return 1
+	}
+
+	return math.Abs(secondary-primary) / math.Abs(primary)
+}
+
+func maxDay(a, b string) string {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minDay(a, b string) string {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,147 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+)
+
+func price(day, open, high, low, close string, volume int64) *api.DailyPrice {
+	return &api.DailyPrice{Day: day, Price: &api.Price{
+		Open:   api.MustParseDecimal(open),
+		High:   api.MustParseDecimal(high),
+		Low:    api.MustParseDecimal(low),
+		Close:  api.MustParseDecimal(close),
+		Volume: volume,
+	}}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   []*api.DailyPrice
+		secondary []*api.DailyPrice
+		expected  SymbolReport
+	}{
+		{
+			name: "agrees within the tolerance",
+			primary: []*api.DailyPrice{
+				price("2022-04-01", "129.6600", "130.2700", "128.0600", "130.1500", 4012373),
+				price("2022-03-31", "131.4000", "131.8200", "129.8900", "130.0200", 4365441),
+			},
+			secondary: []*api.DailyPrice{
+				price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 4100000),
+				price("2022-03-31", "131.41", "131.82", "129.89", "130.02", 4365441),
+			},
+			expected: SymbolReport{From: "2022-03-31", To: "2022-04-01", Compared: 2},
+		},
+		{
+			name: "flags prices and volumes beyond the tolerance",
+			primary: []*api.DailyPrice{
+				price("2022-04-01", "129.6600", "130.2700", "128.0600", "130.1500", 4012373),
+			},
+			secondary: []*api.DailyPrice{
+				price("2022-04-01", "129.66", "130.27", "128.06", "131.50", 2006186),
+			},
+			expected: SymbolReport{From: "2022-04-01", To: "2022-04-01", Compared: 1, Discrepancies: []Discrepancy{
+				{Day: "2022-04-01", Field: "close", Primary: 130.15, Secondary: 131.5, Difference: 0.010373},
+				{Day: "2022-04-01", Field: "volume", Primary: 4012373, Secondary: 2006186, Difference: 0.5},
+			}},
+		},
+		{
+			name: "does not compare volumes a provider does not report",
+			primary: []*api.DailyPrice{
+				price("2022-04-01", "129.6600", "130.2700", "128.0600", "130.1500", 4012373),
+			},
+			secondary: []*api.DailyPrice{
+				price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 0),
+			},
+			expected: SymbolReport{From: "2022-04-01", To: "2022-04-01", Compared: 1},
+		},
+		{
+			name: "reports days missing from either provider within the days both cover",
+			primary: []*api.DailyPrice{
+				price("2022-04-04", "130.00", "130.00", "130.00", "130.00", 0),
+				price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 0),
+				price("2022-03-30", "132.00", "132.00", "132.00", "132.00", 0),
+			},
+			secondary: []*api.DailyPrice{
+				price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 0),
+				price("2022-03-31", "131.40", "131.82", "129.89", "130.02", 0),
+				price("2022-03-29", "133.00", "133.00", "133.00", "133.00", 0),
+			},
+			expected: SymbolReport{
+				From:             "2022-03-30",
+				To:               "2022-04-01",
+				Compared:         1,
+				MissingPrimary:   []string{"2022-03-31"},
+				MissingSecondary: []string{"2022-03-30"},
+			},
+		},
+		{
+			name:      "nothing to compare",
+			primary:   []*api.DailyPrice{price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 0)},
+			secondary: nil,
+			expected:  SymbolReport{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Compare(tt.primary, tt.secondary, DefaultPriceTolerance, DefaultVolumeTolerance))
+		})
+	}
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	primaryMock := mock_api.NewMockProvider(mockController)
+
+	secondaryMock := mock_api.NewMockProvider(mockController)
+
+	defer mockController.Finish()
+
+	now := time.Date(2022, 4, 1, 18, 0, 0, 0, time.UTC)
+
+	primaryMock.EXPECT().Name().AnyTimes().Return(api.AlphaVantage)
+	secondaryMock.EXPECT().Name().AnyTimes().Return(api.CSV)
+
+	primaryMock.EXPECT().Daily(gomock.Any(), "IBM", api.Compact).Times(1).Return(&api.Series{Prices: []*api.DailyPrice{
+		price("2022-04-01", "129.6600", "130.2700", "128.0600", "130.1500", 4012373),
+	}}, nil)
+	secondaryMock.EXPECT().Daily(gomock.Any(), "IBM", api.Compact).Times(1).Return(&api.Series{Prices: []*api.DailyPrice{
+		price("2022-04-01", "129.66", "130.27", "128.06", "130.15", 4012373),
+	}}, nil)
+
+	primaryMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).
+		Return(nil, &api.UpstreamError{Err: api.ErrRateLimited, Message: "5 calls per minute"})
+
+	// BRK.B is read from the secondary provider so it is not reconciled
+	providers := api.NewProviders(primaryMock, map[string]api.Provider{"BRK.B": secondaryMock})
+
+	r := New(providers, secondaryMock, []string{"IBM", "MSFT", "BRK.B"})
+	r.now = func() time.Time { return now }
+
+	assert.Nil(t, r.Report())
+
+	expected := &Report{
+		Generated:       now,
+		PriceTolerance:  DefaultPriceTolerance,
+		VolumeTolerance: DefaultVolumeTolerance,
+		Symbols: []SymbolReport{
+			{Symbol: "IBM", Primary: api.AlphaVantage, Secondary: api.CSV, From: "2022-04-01", To: "2022-04-01", Compared: 1},
+			{Symbol: "MSFT", Primary: api.AlphaVantage, Secondary: api.CSV, Error: "primary: upstream rate limit reached: 5 calls per minute"},
+		},
+	}
+
+	assert.Equal(t, expected, r.Reconcile(context.Background()))
+	assert.Equal(t, expected, r.Report())
+	assert.True(t, expected.Symbols[0].OK())
+	assert.False(t, expected.Symbols[1].OK())
+}
@@ -0,0 +1,506 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/api"
+	"stock_ticker/storage"
+)
+
+const (
+	// _compactCalendarDays is roughly how far back the 100 trading days of a compact response reach
+	_compactCalendarDays = 140
+
+	_defaultHistory = 100
+
+	_defaultRetryBackoff    = 30 * time.Second
+	_defaultMaxRetryBackoff = 10 * time.Minute
+)
+
+// Outcome is the result of refreshing a symbol
+type Outcome string
+
+const (
+	// Refreshed means newer prices were fetched and stored
+	Refreshed Outcome = "refreshed"
+	// Skipped means the cache already held the latest prices so nothing was stored
+	Skipped Outcome = "skipped"
+	// Failed means the prices could not be fetched or stored
+	Failed Outcome = "failed"
+)
+
+// Run records the outcome of refreshing one symbol
+type Run struct {
+	Symbol        string         `json:"symbol"`
+	Started       time.Time      `json:"started"`
+	Finished      time.Time      `json:"finished"`
+	OutputSize    api.OutputSize `json:"output_size,omitempty"`
+	LastRefreshed string         `json:"last_refreshed,omitempty"`
+	Days          int            `json:"days"`
+	Outcome       Outcome        `json:"outcome"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// Option specifies a builder function for configuring a Scheduler
+type Option func(*Scheduler)
+
+// Scheduler keeps the cached prices of the tracked symbols fresh by refreshing them every weekday after market close.
+// Symbols with nothing cached are backfilled with the full history, otherwise the compact output is enough.
+type Scheduler struct {
+	apiClient api.API
+	providers *api.Providers
+	storage   storage.Storage
+	symbols   []string
+
+	location  *time.Location
+	refreshAt time.Duration
+	history   int
+	adjusted  bool
+	after     []func(ctx context.Context)
+	now       func() time.Time
+
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+
+	mu   sync.Mutex
+	runs []Run
+	// last is the latest run of each symbol, kept apart from the capped runs so every symbol keeps its state
+	last map[string]latestRun
+}
+
+// latestRun is the latest run of a symbol along with whether it failed as the provider does not know the symbol
+type latestRun struct {
+	run     Run
+	invalid bool
+}
+
+// New initializes a scheduler that refreshes symbols at 17:00 New York time, an hour after market close
+func New(client api.API, store storage.Storage, symbols []string, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		apiClient: client,
+		storage:   store,
+		symbols:   symbols,
+		location:  time.UTC,
+		refreshAt: 17 * time.Hour,
+		history:   _defaultHistory,
+		now:       time.Now,
+		last:      make(map[string]latestRun),
+
+		retryBackoff:    _defaultRetryBackoff,
+		maxRetryBackoff: _defaultMaxRetryBackoff,
+	}
+
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		s.location = loc
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithRefreshTime sets the time of day symbols are refreshed at
+func WithRefreshTime(hour, minute int) Option {
+	return func(s *Scheduler) {
+		s.refreshAt = time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	}
+}
+
+// WithLocation sets the time zone of the refresh time, that of the exchange
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithHistory sets the number of runs kept
+func WithHistory(n int) Option {
+	return func(s *Scheduler) {
+		s.history = n
+	}
+}
+
+// WithAdjusted fetches the adjusted series so dividends and splits are stored alongside the raw prices
+func WithAdjusted(adjusted bool) Option {
+	return func(s *Scheduler) {
+		s.adjusted = adjusted
+	}
+}
+
+// WithProviders fetches the daily prices of each symbol from its provider rather than the api client
+func WithProviders(p *api.Providers) Option {
+	return func(s *Scheduler) {
+		s.providers = p
+	}
+}
+
+// WithAfterRefresh runs f once the scheduled refresh of every symbol is done, such as checks of the fresh prices
+func WithAfterRefresh(f func(ctx context.Context)) Option {
+	return func(s *Scheduler) {
+		s.after = append(s.after, f)
+	}
+}
+
+// WithRetryBackoff sets how long the warm up waits before retrying the symbols that failed, doubling every retry up
+// to max
+func WithRetryBackoff(backoff, max time.Duration) Option {
+	return func(s *Scheduler) {
+		s.retryBackoff = backoff
+		s.maxRetryBackoff = max
+	}
+}
+
+// WarmUp refreshes every tracked symbol, then retries those that failed with backoff until they are all refreshed or
+// the next scheduled refresh takes over. Symbols the provider does not know are not retried.
+func (s *Scheduler) WarmUp(ctx context.Context) {
+	next := s.next(s.now())
+	backoff := s.retryBackoff
+
+	pending := s.failed(s.RefreshAll(ctx))
+
+	for len(pending) != 0 && s.now().Add(backoff).Before(next) {
+		log.Warn().Strs("symbols", pending).Dur("backoff", backoff).Msg("retrying warm up")
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		retry := make([]Run, 0, len(pending))
+		for _, symbol := range pending {
+			retry = append(retry, s.Refresh(ctx, symbol))
+		}
+
+		pending = s.failed(retry)
+
+		if backoff *= 2; backoff > s.maxRetryBackoff {
+			backoff = s.maxRetryBackoff
+		}
+	}
+}
+
+// failed returns the symbols of the failed runs worth retrying
+func (s *Scheduler) failed(runs []Run) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var symbols []string
+
+	for _, run := range runs {
+		if run.Outcome == Failed && !s.last[run.Symbol].invalid {
+			symbols = append(symbols, run.Symbol)
+		}
+	}
+
+	return symbols
+}
+
+// Run refreshes the symbols on schedule until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.next(s.now())
+
+		log.Info().Time("next", next).Msg("scheduled price refresh")
+
+		timer := time.NewTimer(next.Sub(s.now()))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+			s.RefreshAll(ctx)
+
+			for _, f := range s.after {
+				f(ctx)
+			}
+		}
+	}
+}
+
+// RefreshAll refreshes every tracked symbol in turn
+func (s *Scheduler) RefreshAll(ctx context.Context) []Run {
+	runs := make([]Run, 0, len(s.symbols))
+
+	for _, symbol := range s.symbols {
+		runs = append(runs, s.Refresh(ctx, symbol))
+	}
+
+	return runs
+}
+
+// Refresh brings the cached prices of symbol up to date and records the outcome
+func (s *Scheduler) Refresh(ctx context.Context, symbol string) Run {
+	run := Run{
+		Symbol:  symbol,
+		Started: s.now(),
+	}
+
+	err := s.refresh(ctx, &run)
+	if err != nil {
+		run.Outcome = Failed
+		run.Error = err.Error()
+	}
+
+	run.Finished = s.now()
+
+	s.record(run, err)
+
+	logger := log.Info()
+	if err != nil {
+		logger = log.Error().Err(err)
+	}
+
+	logger.Str("symbol", run.Symbol).
+		Str("outcome", string(run.Outcome)).
+		Str("output_size", string(run.OutputSize)).
+		Str("last_refreshed", run.LastRefreshed).
+		Int("days", run.Days).
+		Dur("duration", run.Finished.Sub(run.Started)).
+		Msg("price refresh")
+
+	return run
+}
+
+func (s *Scheduler) refresh(ctx context.Context, run *Run) error {
+	stored, err := s.storage.GetLastRefreshed(ctx, run.Symbol)
+	if err != nil {
+		return fmt.Errorf("get last refreshed: %w", err)
+	}
+
+	run.LastRefreshed = stored
+
+	// nothing to fetch until the market has closed on a later day
+	if stored != "" && day(stored) >= s.lastClose(s.now()).Format(api.Format) {
+		run.Outcome = Skipped
+
+		return nil
+	}
+
+	run.OutputSize = s.outputSize(stored)
+
+	resp, actions, err := s.fetch(ctx, run.Symbol, run.OutputSize)
+	if err != nil {
+		return fmt.Errorf("get %s series: %w", run.OutputSize, err)
+	}
+
+	// the api has not published anything since the last refresh
+	if stored != "" && resp.MetaData.LastRefreshed == stored {
+		run.Outcome = Skipped
+
+		return nil
+	}
+
+	if err = s.storage.AddPrices(ctx, run.Symbol, resp); err != nil {
+		return fmt.Errorf("add prices: %w", err)
+	}
+
+	if len(actions) != 0 {
+		if err = s.storage.AddCorporateActions(ctx, run.Symbol, actions); err != nil {
+			return fmt.Errorf("add corporate actions: %w", err)
+		}
+	}
+
+	run.Outcome = Refreshed
+	run.LastRefreshed = resp.MetaData.LastRefreshed
+	run.Days = len(resp.DailyPrices)
+
+	return nil
+}
+
+// fetch gets the raw series, along with the corporate actions when adjusted prices are tracked
+func (s *Scheduler) fetch(ctx context.Context, symbol string, size api.OutputSize) (*api.JSONResponse, api.CorporateActions, error) {
+	provider := s.provider(symbol)
+
+	// only the api reports dividends and splits, symbols of other providers are stored as traded
+	if !s.adjusted || provider.Name() != api.AlphaVantage {
+		series, err := provider.Daily(ctx, symbol, size)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return series.Response(), nil, nil
+	}
+
+	resp, err := s.apiClient.GetAdjustedSeries(ctx, symbol, size)
+	if err == nil {
+		return resp.Split()
+	}
+
+	// a refresh given up by its caller is not worth another request
+	if ctx.Err() != nil {
+		return nil, nil, err
+	}
+
+	// the raw prices are stored without actions, those already stored being kept, so the provider falling back to
+	// its secondary one still refreshes the symbol
+	log.Warn().Err(err).Str("symbol", symbol).Msg("adjusted series failed, falling back to the daily series")
+
+	series, dailyErr := provider.Daily(ctx, symbol, size)
+	if dailyErr != nil {
+		return nil, nil, err
+	}
+
+	return series.Response(), nil, nil
+}
+
+// provider returns the provider of the daily prices of symbol
+func (s *Scheduler) provider(symbol string) api.Provider {
+	if s.providers == nil {
+		return s.apiClient
+	}
+
+	return s.providers.For(symbol)
+}
+
+// outputSize backfills the full history when nothing is cached or the gap is wider than a compact response covers
+func (s *Scheduler) outputSize(lastRefreshed string) api.OutputSize {
+	if lastRefreshed == "" {
+		return api.Full
+	}
+
+	last, err := time.Parse(api.Format, day(lastRefreshed))
+	if err != nil || s.now().Sub(last) > _compactCalendarDays*24*time.Hour {
+		return api.Full
+	}
+
+	return api.Compact
+}
+
+// Runs returns the recorded runs, oldest first
+func (s *Scheduler) Runs() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]Run, len(s.runs))
+	copy(runs, s.runs)
+
+	return runs
+}
+
+func (s *Scheduler) record(run Run, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.last[run.Symbol] = latestRun{run: run, invalid: errors.Is(err, api.ErrInvalidSymbol)}
+
+	s.runs = append(s.runs, run)
+	if len(s.runs) > s.history {
+		s.runs = s.runs[len(s.runs)-s.history:]
+	}
+}
+
+// next returns the next weekday refresh time after now
+func (s *Scheduler) next(now time.Time) time.Time {
+	local := now.In(s.location)
+	next := s.refreshTime(local)
+
+	for !next.After(local) || isWeekend(next) {
+		next = s.refreshTime(next.AddDate(0, 0, 1))
+	}
+
+	return next
+}
+
+// lastClose returns the latest weekday whose refresh time has passed
+func (s *Scheduler) lastClose(now time.Time) time.Time {
+	local := now.In(s.location)
+	last := s.refreshTime(local)
+
+	if local.Before(last) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	for isWeekend(last) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	return last
+}
+
+// refreshTime returns the refresh time on the day of t, wall clock time so it holds across daylight saving changes
+func (s *Scheduler) refreshTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), int(s.refreshAt/time.Hour), int(s.refreshAt%time.Hour/time.Minute), 0, 0, s.location)
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// day returns the date part of a last refreshed value, intraday series include the time
+func day(lastRefreshed string) string {
+	if len(lastRefreshed) > len(api.Format) {
+		return lastRefreshed[:len(api.Format)]
+	}
+
+	return lastRefreshed
+}
+
+// CacheState is how ready the cached prices of a symbol are to be served
+type CacheState string
+
+const (
+	// Warming means the symbol has not been refreshed yet
+	Warming CacheState = "warming"
+	// Ready means the last refresh succeeded
+	Ready CacheState = "ready"
+	// Stale means the last refresh failed but earlier prices are cached
+	Stale CacheState = "stale"
+	// Empty means the last refresh failed and nothing is cached
+	Empty CacheState = "empty"
+	// Invalid means the last refresh failed as the provider does not know the symbol and nothing is cached
+	Invalid CacheState = "invalid"
+)
+
+// SymbolStatus is the cache state of a tracked symbol
+type SymbolStatus struct {
+	Symbol  string     `json:"symbol"`
+	State   CacheState `json:"state"`
+	LastRun *Run       `json:"last_run,omitempty"`
+}
+
+// Status returns the cache state of every tracked symbol from its latest run
+func (s *Scheduler) Status() []SymbolStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := make([]SymbolStatus, 0, len(s.symbols))
+
+	for _, symbol := range s.symbols {
+		st := SymbolStatus{Symbol: symbol, State: Warming}
+
+		if last, ok := s.last[symbol]; ok {
+			run := last.run
+			st.LastRun = &run
+
+			switch {
+			case run.Outcome != Failed:
+				st.State = Ready
+			case run.LastRefreshed != "":
+				st.State = Stale
+			case last.invalid:
+				st.State = Invalid
+			default:
+				st.State = Empty
+			}
+		}
+
+		status = append(status, st)
+	}
+
+	return status
+}
@@ -0,0 +1,329 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/storage/mocks"
+)
+
+func TestScheduler_Refresh(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	// a friday evening after the refresh time
+	now := time.Date(2022, 4, 1, 18, 0, 0, 0, newYork)
+
+	series := &api.JSONResponse{
+		MetaData: api.MD{Symbol: "MSFT", LastRefreshed: "2022-04-01"},
+		DailyPrices: api.TimeSeriesDaily{
+			"2022-04-01": {Close: api.MustParseDecimal("309.4200")},
+			"2022-03-31": {Close: api.MustParseDecimal("308.3100")},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		apiMockOutcomes     func(apiMock *mock_api.MockAPI)
+		adjusted            bool
+		expected            Run
+	}{
+		{
+			name: "backfills the full history when nothing is cached",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("", nil)
+				storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(api.NewSeries("MSFT", api.AlphaVantage, series), nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Full, LastRefreshed: "2022-04-01", Days: 2, Outcome: Refreshed},
+		},
+		{
+			name:     "stores corporate actions when tracking adjusted prices",
+			adjusted: true,
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-03-31", nil)
+				storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+				storageMock.EXPECT().AddCorporateActions(gomock.Any(), "MSFT", api.CorporateActions{
+					"2022-04-01": {DividendAmount: api.MustParseDecimal("0.6200"), SplitCoefficient: api.MustParseDecimal("1.0"), Factor: 1 - 0.62/308.31},
+				}).Times(1).Return(nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Name().Times(1).Return(api.AlphaVantage)
+				apiMock.EXPECT().GetAdjustedSeries(gomock.Any(), "MSFT", api.Compact).Times(1).Return(&api.AdjustedResponse{
+					MetaData: series.MetaData,
+					DailyPrices: api.TimeSeriesDailyAdjusted{
+						"2022-04-01": {Close: api.MustParseDecimal("309.4200"), DividendAmount: api.MustParseDecimal("0.6200"), SplitCoefficient: api.MustParseDecimal("1.0")},
+						"2022-03-31": {Close: api.MustParseDecimal("308.3100"), DividendAmount: api.MustParseDecimal("0.0000"), SplitCoefficient: api.MustParseDecimal("1.0")},
+					},
+				}, nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Compact, LastRefreshed: "2022-04-01", Days: 2, Outcome: Refreshed},
+		},
+		{
+			name:     "falls back to the daily series when the adjusted series fails",
+			adjusted: true,
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-03-31", nil)
+				storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Name().Times(1).Return(api.AlphaVantage)
+				apiMock.EXPECT().GetAdjustedSeries(gomock.Any(), "MSFT", api.Compact).Times(1).
+					Return(nil, &api.UpstreamError{Err: api.ErrRateLimited})
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).Return(api.NewSeries("MSFT", api.CSV, series), nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Compact, LastRefreshed: "2022-04-01", Days: 2, Outcome: Refreshed},
+		},
+		{
+			name: "fetches the compact output for an incremental update",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-03-31", nil)
+				storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).Return(api.NewSeries("MSFT", api.AlphaVantage, series), nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Compact, LastRefreshed: "2022-04-01", Days: 2, Outcome: Refreshed},
+		},
+		{
+			name: "backfills when the gap is wider than the compact output",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2021-06-30", nil)
+				storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(api.NewSeries("MSFT", api.AlphaVantage, series), nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Full, LastRefreshed: "2022-04-01", Days: 2, Outcome: Refreshed},
+		},
+		{
+			name: "skips the fetch when the cache holds the last close",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-04-01", nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {},
+			expected:        Run{Symbol: "MSFT", LastRefreshed: "2022-04-01", Outcome: Skipped},
+		},
+		{
+			name: "skips storing when the api has nothing newer",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-03-31", nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).
+					Return(&api.Series{Symbol: "MSFT", Source: api.AlphaVantage, LastRefreshed: "2022-03-31"}, nil)
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Compact, LastRefreshed: "2022-03-31", Outcome: Skipped},
+		},
+		{
+			name: "records failures",
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("2022-03-31", nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).
+					Return(nil, errors.New("test error"))
+			},
+			expected: Run{Symbol: "MSFT", OutputSize: api.Compact, LastRefreshed: "2022-03-31", Outcome: Failed, Error: "get compact series: test error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+			tt.apiMockOutcomes(apiMock)
+
+			s := New(apiMock, storageMock, []string{"MSFT"}, WithLocation(newYork), WithAdjusted(tt.adjusted))
+			s.now = func() time.Time { return now }
+
+			run := s.Refresh(context.Background(), "MSFT")
+
+			tt.expected.Started = now
+			tt.expected.Finished = now
+
+			assert.Equal(t, tt.expected, run)
+			assert.Equal(t, []Run{tt.expected}, s.Runs())
+		})
+	}
+}
+
+func TestScheduler_next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		next      time.Time
+		lastClose time.Time
+	}{
+		{
+			name:      "weekday before the refresh time",
+			now:       time.Date(2022, 3, 31, 10, 0, 0, 0, newYork),
+			next:      time.Date(2022, 3, 31, 17, 0, 0, 0, newYork),
+			lastClose: time.Date(2022, 3, 30, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:      "friday after the refresh time waits for monday",
+			now:       time.Date(2022, 4, 1, 18, 0, 0, 0, newYork),
+			next:      time.Date(2022, 4, 4, 17, 0, 0, 0, newYork),
+			lastClose: time.Date(2022, 4, 1, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:      "weekend",
+			now:       time.Date(2022, 4, 3, 12, 0, 0, 0, newYork),
+			next:      time.Date(2022, 4, 4, 17, 0, 0, 0, newYork),
+			lastClose: time.Date(2022, 4, 1, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:      "in utc across daylight saving",
+			now:       time.Date(2022, 3, 11, 23, 0, 0, 0, time.UTC),
+			next:      time.Date(2022, 3, 14, 17, 0, 0, 0, newYork),
+			lastClose: time.Date(2022, 3, 11, 17, 0, 0, 0, newYork),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, nil, nil, WithLocation(newYork))
+
+			assert.True(t, tt.next.Equal(s.next(tt.now)), "expected %v got %v", tt.next, s.next(tt.now))
+			assert.True(t, tt.lastClose.Equal(s.lastClose(tt.now)), "expected %v got %v", tt.lastClose, s.lastClose(tt.now))
+		})
+	}
+}
+
+func TestScheduler_Status(t *testing.T) {
+	s := New(nil, nil, []string{"MSFT", "IBM", "AAPL", "TSLA", "XXXX"})
+
+	invalid := fmt.Errorf("get full series: %w", &api.UpstreamError{Err: api.ErrInvalidSymbol})
+
+	s.record(Run{Symbol: "IBM", Outcome: Failed, Error: "test error"}, errors.New("test error"))
+	s.record(Run{Symbol: "IBM", Outcome: Refreshed, LastRefreshed: "2022-04-01"}, nil)
+	s.record(Run{Symbol: "AAPL", Outcome: Failed, LastRefreshed: "2022-03-31", Error: "test error"}, errors.New("test error"))
+	s.record(Run{Symbol: "TSLA", Outcome: Failed, Error: "test error"}, errors.New("test error"))
+	s.record(Run{Symbol: "XXXX", Outcome: Failed, Error: invalid.Error()}, invalid)
+
+	assert.Equal(t, []SymbolStatus{
+		{Symbol: "MSFT", State: Warming},
+		{Symbol: "IBM", State: Ready, LastRun: &Run{Symbol: "IBM", Outcome: Refreshed, LastRefreshed: "2022-04-01"}},
+		{Symbol: "AAPL", State: Stale, LastRun: &Run{Symbol: "AAPL", Outcome: Failed, LastRefreshed: "2022-03-31", Error: "test error"}},
+		{Symbol: "TSLA", State: Empty, LastRun: &Run{Symbol: "TSLA", Outcome: Failed, Error: "test error"}},
+		{Symbol: "XXXX", State: Invalid, LastRun: &Run{Symbol: "XXXX", Outcome: Failed, Error: invalid.Error()}},
+	}, s.Status())
+}
+
+func TestScheduler_StatusBeyondHistory(t *testing.T) {
+	symbols := []string{"MSFT", "IBM", "AAPL"}
+
+	s := New(nil, nil, symbols, WithHistory(2))
+
+	for _, symbol := range symbols {
+		s.record(Run{Symbol: symbol, Outcome: Refreshed, LastRefreshed: "2022-04-01"}, nil)
+	}
+
+	// the history only keeps the latest runs but every symbol keeps its state
+	assert.Len(t, s.Runs(), 2)
+
+	for _, st := range s.Status() {
+		assert.Equal(t, Ready, st.State, st.Symbol)
+	}
+}
+
+func TestScheduler_WarmUp(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	series := &api.JSONResponse{
+		MetaData:    api.MD{Symbol: "MSFT", LastRefreshed: "2022-04-01"},
+		DailyPrices: api.TimeSeriesDaily{"2022-04-01": {Close: api.MustParseDecimal("309.4200")}},
+	}
+
+	invalid := &api.UpstreamError{Err: api.ErrInvalidSymbol}
+
+	// the first two refreshes of MSFT fail, the symbol the provider does not know is not retried
+	storageMock.EXPECT().GetLastRefreshed(gomock.Any(), gomock.Any()).AnyTimes().Return("", nil)
+	storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", series).Times(1).Return(nil)
+	gomock.InOrder(
+		apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(2).Return(nil, &api.UpstreamError{Err: api.ErrRateLimited}),
+		apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(api.NewSeries("MSFT", api.AlphaVantage, series), nil),
+	)
+	apiMock.EXPECT().Daily(gomock.Any(), "XXXX", api.Full).Times(1).Return(nil, invalid)
+
+	s := New(apiMock, storageMock, []string{"MSFT", "XXXX"}, WithLocation(newYork), WithRetryBackoff(time.Millisecond, 2*time.Millisecond))
+	s.now = func() time.Time {
+		// a friday morning, hours before the scheduled refresh
+		return time.Date(2022, 4, 1, 9, 0, 0, 0, newYork)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.WarmUp(ctx)
+
+	assert.NoError(t, ctx.Err())
+
+	status := s.Status()
+	assert.Equal(t, Ready, status[0].State)
+	assert.Equal(t, Invalid, status[1].State)
+	assert.Len(t, s.Runs(), 4)
+}
+
+func TestScheduler_WarmUpGivesWayToSchedule(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	storageMock.EXPECT().GetLastRefreshed(gomock.Any(), "MSFT").Times(1).Return("", nil)
+	apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(nil, &api.UpstreamError{Err: api.ErrRateLimited})
+
+	s := New(apiMock, storageMock, []string{"MSFT"}, WithLocation(newYork), WithRetryBackoff(time.Hour, time.Hour))
+	s.now = func() time.Time {
+		// a friday afternoon, the scheduled refresh comes before the retry would
+		return time.Date(2022, 4, 1, 16, 30, 0, 0, newYork)
+	}
+
+	s.WarmUp(context.Background())
+
+	assert.Equal(t, Empty, s.Status()[0].State)
+}
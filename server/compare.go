@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/analytics"
+	"stock_ticker/api"
+)
+
+const (
+	_maxCompared = 10
+
+	_errNoCommonDays = "the symbols have no trading days in common"
+)
+
+// compareQuery holds the validated values of a request comparing symbols
+type compareQuery struct {
+	priceQuery
+	symbols   []string
+	benchmark string
+}
+
+// compareResponse is the response comparing symbols over the days they all have prices for. Every point of the
+// performance holds its date and the close of each symbol rebased to 100 on the first day e.g.
+// {"date":"2022-04-01","IBM":98.64,"MSFT":101.2}. Correlations are of the daily returns and null when a symbol's
+// price does not vary.
+type compareResponse struct {
+	Symbols      []string                       `json:"symbols"`
+	Benchmark    string                         `json:"benchmark"`
+	Interval     string                         `json:"interval"`
+	Adjusted     bool                           `json:"adjusted"`
+	From         string                         `json:"from"`
+	To           string                         `json:"to"`
+	Count        int                            `json:"count"`
+	Performance  []map[string]interface{}       `json:"performance"`
+	Correlations map[string]map[string]*float64 `json:"correlations"`
+	Beta         map[string]*float64            `json:"beta"`
+}
+
+// parseCompareQuery validates the comma separated symbols, the benchmark, the first symbol by default, and the
+// price query parameters
+func parseCompareQuery(values url.Values, defaultDays int) (compareQuery, error) {
+	var symbols []string
+
+	seen := make(map[string]bool)
+
+	for _, symbol := range strings.Split(values.Get("symbols"), ",") {
+		if !symbolRegex.MatchString(symbol) {
+			return compareQuery{}, fmt.Errorf("invalid symbol %q", symbol)
+		}
+
+		if symbol = strings.ToUpper(symbol); !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if len(symbols) < 2 || len(symbols) > _maxCompared {
+		return compareQuery{}, fmt.Errorf("invalid symbols: expected between 2 and %d symbols", _maxCompared)
+	}
+
+	prices, err := parsePriceQuery(symbols[0], values, defaultDays)
+	if err != nil {
+		return compareQuery{}, err
+	}
+
+	q := compareQuery{priceQuery: prices, symbols: symbols, benchmark: symbols[0]}
+
+	if benchmark := values.Get("benchmark"); benchmark != "" {
+		if !symbolRegex.MatchString(benchmark) {
+			return compareQuery{}, fmt.Errorf("invalid benchmark %q", benchmark)
+		}
+
+		q.benchmark = strings.ToUpper(benchmark)
+	}
+
+	return q, nil
+}
+
+// GetComparison is a handler responsible for comparing the performance of symbols
+func (h *handler) GetComparison(ctx context.Context, w http.ResponseWriter, q compareQuery) {
+	resp, err := h.compare(ctx, q)
+	if errors.Is(err, analytics.ErrTooFewBars) {
+		writeError(w, http.StatusUnprocessableEntity, _errNoCommonDays)
+
+		return
+	}
+
+	if err != nil {
+		log.Error().Err(err).Strs("symbols", q.symbols).Msg("compare symbols")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("compare symbols")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// compare reads the prices of every symbol and of the benchmark and compares them over the days they all have
+func (h *handler) compare(ctx context.Context, q compareQuery) (*compareResponse, error) {
+	symbols := make([]string, 0, len(q.symbols)+1)
+	symbols = append(symbols, q.symbols...)
+	symbols = append(symbols, q.benchmark)
+
+	series := make(map[string][]analytics.Bar, len(symbols))
+
+	for _, symbol := range symbols {
+		if _, ok := series[symbol]; ok {
+			continue
+		}
+
+		symbolQuery := q.priceQuery
+		symbolQuery.symbol = symbol
+
+		prices, _, err := h.prices(ctx, symbolQuery)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+
+		series[symbol] = analytics.Bars(prices.DailyPrices)
+	}
+
+	days, closes := analytics.Align(series)
+	if len(days) < 2 {
+		return nil, analytics.ErrTooFewBars
+	}
+
+	resp := &compareResponse{
+		Symbols:      q.symbols,
+		Benchmark:    q.benchmark,
+		Interval:     string(q.period),
+		Adjusted:     q.adjusted,
+		From:         days[0],
+		To:           days[len(days)-1],
+		Count:        len(days),
+		Performance:  make([]map[string]interface{}, len(days)),
+		Correlations: make(map[string]map[string]*float64, len(q.symbols)),
+		Beta:         make(map[string]*float64, len(q.symbols)),
+	}
+
+	for i, day := range days {
+		resp.Performance[i] = map[string]interface{}{"date": day}
+	}
+
+	returns := make(map[string][]float64, len(closes))
+	for symbol, values := range closes {
+		returns[symbol] = analytics.Returns(values)
+	}
+
+	for _, symbol := range q.symbols {
+		for i, value := range analytics.Rebase(closes[symbol], 100) {
+			resp.Performance[i][symbol] = api.FixedPrecision(value, 4)
+		}
+
+		resp.Correlations[symbol] = make(map[string]*float64, len(q.symbols))
+		for _, other := range q.symbols {
+			resp.Correlations[symbol][other] = ratio(analytics.Correlation(returns[symbol], returns[other]))
+		}
+
+		resp.Beta[symbol] = ratio(analytics.Beta(returns[symbol], returns[q.benchmark]))
+	}
+
+	return resp, nil
+}
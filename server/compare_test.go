@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/storage/mocks"
+)
+
+func Test_handler_GetComparison(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	closes := func(values map[string]string) []*api.DailyPrice {
+		prices := make([]*api.DailyPrice, 0, len(values))
+		for _, day := range []string{"2022-04-01", "2022-03-31", "2022-03-30"} {
+			if value, ok := values[day]; ok {
+				prices = append(prices, &api.DailyPrice{Day: day, Price: &api.Price{Close: api.MustParseDecimal(value)}})
+			}
+		}
+
+		return prices
+	}
+
+	msft := closes(map[string]string{"2022-04-01": "309.4200", "2022-03-31": "308.3100", "2022-03-30": "313.8600"})
+	ibm := closes(map[string]string{"2022-04-01": "130.1500", "2022-03-31": "130.0200", "2022-03-30": "131.3600"})
+
+	tests := []struct {
+		name                string
+		r                   *http.Request
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		status              int
+		expected            string
+	}{
+		{
+			name: "compares the symbols against the first one",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/compare?symbols=msft,IBM&days=3", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "MSFT", 3).Times(1).Return(msft, 310.53, nil)
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "IBM", 3).Times(1).Return(ibm, 130.51, nil)
+			},
+			status:   http.StatusOK,
+			expected: `{"symbols":["MSFT","IBM"],"benchmark":"MSFT","interval":"daily","adjusted":false,"from":"2022-03-30","to":"2022-04-01","count":3,"performance":[{"IBM":100,"MSFT":100,"date":"2022-03-30"},{"IBM":98.9799,"MSFT":98.2317,"date":"2022-03-31"},{"IBM":99.0789,"MSFT":98.5854,"date":"2022-04-01"}],"correlations":{"IBM":{"IBM":1,"MSFT":1},"MSFT":{"IBM":1,"MSFT":1}},"beta":{"IBM":0.526272,"MSFT":1}}`,
+		},
+		{
+			name: "a benchmark that is not compared is read too",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/compare?symbols=MSFT,IBM&benchmark=SPY&days=3", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "MSFT", 3).Times(1).Return(msft, 310.53, nil)
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "IBM", 3).Times(1).Return(ibm, 130.51, nil)
+				// only one day in common, the cache holding fewer days than asked for so the api is called too
+				spy := closes(map[string]string{"2022-04-01": "452.9200"})
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "SPY", 3).Times(1).Return(spy, 452.92, nil)
+				apiMock.EXPECT().Daily(gomock.Any(), "SPY", api.Compact).Times(1).
+					Return(&api.Series{Symbol: "SPY", Source: api.AlphaVantage, Prices: spy}, nil)
+			},
+			status:   http.StatusUnprocessableEntity,
+			expected: errorBody(_errNoCommonDays),
+		},
+		{
+			name:                "one symbol",
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/compare?symbols=MSFT", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			status:              http.StatusBadRequest,
+			expected:            errorBody("invalid symbols: expected between 2 and 10 symbols"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+
+			h := &handler{
+				apiClient: apiMock,
+				redis:     storageMock,
+				nDays:     10,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func Test_parseCompareQuery(t *testing.T) {
+	q, err := parseCompareQuery(url.Values{"symbols": {"ibm,MSFT,IBM"}, "benchmark": {"spy"}, "adjusted": {"true"}}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, compareQuery{
+		priceQuery: priceQuery{symbol: "IBM", days: 10, adjusted: true, period: api.Daily},
+		symbols:    []string{"IBM", "MSFT"},
+		benchmark:  "SPY",
+	}, q)
+
+	_, err = parseCompareQuery(url.Values{"symbols": {"IBM,MS FT"}}, 10)
+	assert.EqualError(t, err, `invalid symbol "MS FT"`)
+}
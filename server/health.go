@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/api"
+	"stock_ticker/scheduler"
+	"stock_ticker/storage"
+)
+
+const (
+	_statusOK       = "ok"
+	_statusReady    = "ready"
+	_statusNotReady = "not ready"
+)
+
+// cacheStatus reports the cache state of the tracked symbols
+type cacheStatus interface {
+	Status() []scheduler.SymbolStatus
+}
+
+type healthHandler struct {
+	apiClient api.API
+	storage   storage.Storage
+	cache     cacheStatus
+}
+
+// dependency is the state of something the service relies on
+type dependency struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// upstream is the state of the stock prices api
+type upstream struct {
+	Reachable bool             `json:"reachable"`
+	Health    api.Health       `json:"health"`
+	Quota     *api.QuotaStatus `json:"quota,omitempty"`
+}
+
+type readiness struct {
+	Status   string                   `json:"status"`
+	Storage  dependency               `json:"storage"`
+	Symbols  []scheduler.SymbolStatus `json:"symbols"`
+	Upstream upstream                 `json:"upstream"`
+}
+
+func NewHealthHandler(client api.API, store storage.Storage, cache cacheStatus) healthHandler {
+	return healthHandler{
+		apiClient: client,
+		storage:   store,
+		cache:     cache,
+	}
+}
+
+// Healthz is the liveness probe, the service is alive as long as it can serve requests
+func (h *healthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp, _ := json.Marshal(map[string]string{"status": _statusOK})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// Readyz is the readiness probe. The service is ready once the storage can be reached and every tracked symbol has been
+// warmed up, failed refreshes that left earlier prices cached and symbols the provider does not know still count as
+// ready. The api's reachability and quota are reported but do not affect readiness as cached prices are served
+// without it.
+func (h *healthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ready := readiness{
+		Status:  _statusReady,
+		Storage: dependency{OK: true},
+		Symbols: h.cache.Status(),
+	}
+
+	if err := h.storage.Ping(r.Context()); err != nil {
+		ready.Status = _statusNotReady
+		ready.Storage = dependency{Error: err.Error()}
+	}
+
+	for _, symbol := range ready.Symbols {
+		if symbol.State == scheduler.Warming || symbol.State == scheduler.Empty {
+			ready.Status = _statusNotReady
+		}
+	}
+
+	health := h.apiClient.Health()
+	ready.Upstream = upstream{
+		Reachable: health.Reachable(),
+		Health:    health,
+	}
+
+	if quota := h.apiClient.Quota(); quota != nil {
+		status, err := quota.Remaining(r.Context())
+		if err != nil {
+			log.Error().Err(err).Msg("get quota")
+		} else {
+			ready.Upstream.Quota = &status
+		}
+	}
+
+	resp, err := json.Marshal(ready)
+	if err != nil {
+		log.Error().Err(err).Msg("readiness")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	if ready.Status != _statusReady {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.Write(resp)
+}
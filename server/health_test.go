@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/scheduler"
+	"stock_ticker/storage/mocks"
+)
+
+type fakeCache []scheduler.SymbolStatus
+
+func (f fakeCache) Status() []scheduler.SymbolStatus {
+	return f
+}
+
+func Test_healthHandler_Readyz(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	success := time.Date(2022, 4, 1, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		cache               fakeCache
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		health              api.Health
+		status              int
+		expected            readiness
+	}{
+		{
+			name:  "ready once every symbol is warmed up",
+			cache: fakeCache{{Symbol: "MSFT", State: scheduler.Ready}, {Symbol: "IBM", State: scheduler.Stale}},
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+			},
+			health: api.Health{LastSuccess: success},
+			status: http.StatusOK,
+			expected: readiness{
+				Status:   _statusReady,
+				Storage:  dependency{OK: true},
+				Symbols:  []scheduler.SymbolStatus{{Symbol: "MSFT", State: scheduler.Ready}, {Symbol: "IBM", State: scheduler.Stale}},
+				Upstream: upstream{Reachable: true, Health: api.Health{LastSuccess: success}},
+			},
+		},
+		{
+			name:  "symbols the provider does not know do not hold up readiness",
+			cache: fakeCache{{Symbol: "MSFT", State: scheduler.Ready}, {Symbol: "XXXX", State: scheduler.Invalid}},
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+			},
+			status: http.StatusOK,
+			expected: readiness{
+				Status:   _statusReady,
+				Storage:  dependency{OK: true},
+				Symbols:  []scheduler.SymbolStatus{{Symbol: "MSFT", State: scheduler.Ready}, {Symbol: "XXXX", State: scheduler.Invalid}},
+				Upstream: upstream{Reachable: true},
+			},
+		},
+		{
+			name:  "not ready while warming up",
+			cache: fakeCache{{Symbol: "MSFT", State: scheduler.Warming}},
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+			},
+			status: http.StatusServiceUnavailable,
+			expected: readiness{
+				Status:   _statusNotReady,
+				Storage:  dependency{OK: true},
+				Symbols:  []scheduler.SymbolStatus{{Symbol: "MSFT", State: scheduler.Warming}},
+				Upstream: upstream{Reachable: true},
+			},
+		},
+		{
+			name:  "not ready when the storage is down",
+			cache: fakeCache{{Symbol: "MSFT", State: scheduler.Ready}},
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+			},
+			health: api.Health{LastSuccess: success, LastError: "timeout", LastErrorAt: success.Add(time.Hour)},
+			status: http.StatusServiceUnavailable,
+			expected: readiness{
+				Status:   _statusNotReady,
+				Storage:  dependency{Error: "connection refused"},
+				Symbols:  []scheduler.SymbolStatus{{Symbol: "MSFT", State: scheduler.Ready}},
+				Upstream: upstream{Health: api.Health{LastSuccess: success, LastError: "timeout", LastErrorAt: success.Add(time.Hour)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+			apiMock.EXPECT().Health().Times(1).Return(tt.health)
+			apiMock.EXPECT().Quota().Times(1).Return(nil)
+
+			h := NewHealthHandler(apiMock, storageMock, tt.cache)
+
+			w := httptest.NewRecorder()
+			h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var res readiness
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Errorf("test error :%e", err)
+			}
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func Test_healthHandler_Healthz(t *testing.T) {
+	h := NewHealthHandler(nil, nil, fakeCache{})
+
+	w := httptest.NewRecorder()
+	h.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/analytics"
+	"stock_ticker/api"
+)
+
+const (
+	// _maxPeriod is about a year of trading days
+	_maxPeriod = 260
+)
+
+// indicatorQuery holds the validated values of a request for an indicator
+type indicatorQuery struct {
+	priceQuery
+	name   string
+	params analytics.Params
+}
+
+// indicatorResponse is the response of an indicator, every point holds its date and the value of each line of the
+// indicator e.g. {"date":"2022-04-01","value":310.12} or {"date":"2022-04-01","macd":1.2,"signal":0.8,"histogram":0.4}
+type indicatorResponse struct {
+	Symbol    string                   `json:"symbol"`
+	Indicator string                   `json:"indicator"`
+	Interval  string                   `json:"interval"`
+	Adjusted  bool                     `json:"adjusted"`
+	Points    []map[string]interface{} `json:"points"`
+}
+
+// parseIndicatorQuery validates the price query parameters along with the period, fast, slow, signal and k
+// parameters of the indicator
+func parseIndicatorQuery(symbol, name string, values url.Values, defaultDays int) (indicatorQuery, error) {
+	prices, err := parsePriceQuery(symbol, values, defaultDays)
+	if err != nil {
+		return indicatorQuery{}, err
+	}
+
+	q := indicatorQuery{priceQuery: prices, name: name}
+
+	for param, value := range map[string]*int{
+		"period": &q.params.Period,
+		"fast":   &q.params.Fast,
+		"slow":   &q.params.Slow,
+		"signal": &q.params.Signal,
+	} {
+		raw := values.Get(param)
+		if raw == "" {
+			continue
+		}
+
+		if *value, err = strconv.Atoi(raw); err != nil || *value < 1 || *value > _maxPeriod {
+			return indicatorQuery{}, fmt.Errorf("invalid %s %q: expected a number between 1 and %d", param, raw, _maxPeriod)
+		}
+	}
+
+	if k := values.Get("k"); k != "" {
+		if q.params.K, err = strconv.ParseFloat(k, 64); err != nil || q.params.K <= 0 || q.params.K > 10 {
+			return indicatorQuery{}, fmt.Errorf("invalid k %q: expected a number above 0 up to 10", k)
+		}
+	}
+
+	if _, err = analytics.Lookback(name, q.params); err != nil {
+		return indicatorQuery{}, err
+	}
+
+	return q, nil
+}
+
+// GetIndicator is a handler responsible for computing an indicator over the prices matching the query
+func (h *handler) GetIndicator(ctx context.Context, w http.ResponseWriter, q indicatorQuery) {
+	points, err := h.indicator(ctx, q)
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Str("indicator", q.name).Msg("compute indicator")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	resp := indicatorResponse{
+		Symbol:    q.symbol,
+		Indicator: q.name,
+		Interval:  string(q.period),
+		Adjusted:  q.adjusted,
+		Points:    make([]map[string]interface{}, 0, len(points)),
+	}
+
+	for _, point := range points {
+		values := map[string]interface{}{"date": point.Date}
+		for line, value := range point.Values {
+			values[line] = api.FixedPrecision(value, 4)
+		}
+
+		resp.Points = append(resp.Points, values)
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("compute indicator")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// indicator computes the indicator over the prices of the query. The prices before the query that the indicator
+// needs for its first values are read too so a point is returned for every bar asked for.
+func (h *handler) indicator(ctx context.Context, q indicatorQuery) ([]analytics.Point, error) {
+	lookback, err := analytics.Lookback(q.name, q.params)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, _, err := h.prices(ctx, warmUp(q.priceQuery, lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := analytics.Compute(q.name, analytics.Bars(prices.DailyPrices), q.params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !q.isRange() && len(points) > q.days:
+		points = points[len(points)-q.days:]
+	case !q.from.IsZero():
+		first := 0
+		for first < len(points) && points[first].Date < q.from.Format(api.Format) {
+			first++
+		}
+
+		points = points[first:]
+	}
+
+	return points, nil
+}
+
+// warmUp returns the query extended back by lookback bars
+func warmUp(q priceQuery, lookback int) priceQuery {
+	switch {
+	case !q.isRange():
+		q.days += lookback
+		if q.days > _maxDays {
+			q.days = _maxDays
+		}
+	case !q.from.IsZero() && q.period == api.Daily:
+		// a week of calendar days holds 5 trading days, with a week to spare for holidays
+		q.from = q.from.AddDate(0, 0, -(lookback*7/5 + 7))
+	case !q.from.IsZero():
+		q.from = q.period.Start(q.from, lookback+1)
+	}
+
+	return q
+}
+
+// writeIndicatorError maps errors of the indicator parameters to a status code
+func writeIndicatorError(w http.ResponseWriter, err error) {
+	if errors.Is(err, analytics.ErrUnknownIndicator) {
+		writeError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, err.Error())
+}
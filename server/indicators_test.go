@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/analytics"
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/storage/mocks"
+)
+
+func Test_handler_GetIndicator(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	prices := []*api.DailyPrice{
+		{Day: "2022-04-01", Price: &api.Price{Open: api.MustParseDecimal("309.3700"), High: api.MustParseDecimal("310.1300"), Low: api.MustParseDecimal("305.5400"), Close: api.MustParseDecimal("309.4200"), Volume: 27110529}},
+		{Day: "2022-03-31", Price: &api.Price{Open: api.MustParseDecimal("313.9000"), High: api.MustParseDecimal("315.1400"), Low: api.MustParseDecimal("307.8900"), Close: api.MustParseDecimal("308.3100"), Volume: 33422070}},
+		{Day: "2022-03-30", Price: &api.Price{Open: api.MustParseDecimal("313.7600"), High: api.MustParseDecimal("315.9500"), Low: api.MustParseDecimal("311.5800"), Close: api.MustParseDecimal("313.8600"), Volume: 28163555}},
+	}
+
+	tests := []struct {
+		name                string
+		r                   *http.Request
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		status              int
+		expected            string
+	}{
+		{
+			name: "reads the days before the first point the indicator needs",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/msft/indicators/sma?period=2&days=2", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "MSFT", 3).Times(1).Return(prices, 310.53, nil)
+			},
+			status:   http.StatusOK,
+			expected: `{"symbol":"MSFT","indicator":"sma","interval":"daily","adjusted":false,"points":[{"date":"2022-03-31","value":311.085},{"date":"2022-04-01","value":308.865}]}`,
+		},
+		{
+			name: "points of a range start at its first day",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/indicators/bbands?period=2&k=1&from=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				from := time.Date(2022, 3, 24, 0, 0, 0, 0, time.UTC)
+
+				// the cache starts days after the range so the full history is cached first
+				gomock.InOrder(
+					storageMock.EXPECT().GetPriceRange(gomock.Any(), "MSFT", from, time.Time{}, 0).Times(1).Return(prices[:2], 308.87, nil),
+					apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(&api.Series{Symbol: "MSFT", Source: api.AlphaVantage}, nil),
+					storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", gomock.Any()).Times(1).Return(nil),
+					storageMock.EXPECT().GetPriceRange(gomock.Any(), "MSFT", from, time.Time{}, 0).Times(1).Return(prices, 310.53, nil),
+				)
+			},
+			status:   http.StatusOK,
+			expected: `{"symbol":"MSFT","indicator":"bbands","interval":"daily","adjusted":false,"points":[{"date":"2022-04-01","lower":308.31,"middle":308.865,"upper":309.42}]}`,
+		},
+		{
+			name:                "unknown indicator",
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/indicators/obv", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			status:              http.StatusNotFound,
+			expected:            errorBody(`unknown indicator "obv"`),
+		},
+		{
+			name:                "invalid period",
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/indicators/rsi?period=0", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			status:              http.StatusBadRequest,
+			expected:            errorBody(`invalid period "0": expected a number between 1 and 260`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+
+			h := &handler{
+				apiClient: apiMock,
+				redis:     storageMock,
+				nDays:     10,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func Test_parseIndicatorQuery(t *testing.T) {
+	q, err := parseIndicatorQuery("msft", "macd", url.Values{"fast": {"5"}, "slow": {"10"}, "signal": {"3"}, "adjusted": {"true"}}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, indicatorQuery{
+		priceQuery: priceQuery{symbol: "MSFT", days: 10, adjusted: true, period: api.Daily},
+		name:       "macd",
+		params:     analytics.Params{Fast: 5, Slow: 10, Signal: 3},
+	}, q)
+
+	_, err = parseIndicatorQuery("msft", "macd", url.Values{"fast": {"10"}, "slow": {"5"}}, 10)
+	assert.ErrorIs(t, err, analytics.ErrInvalidParams)
+
+	_, err = parseIndicatorQuery("msft", "bbands", url.Values{"k": {"-1"}}, 10)
+	assert.EqualError(t, err, `invalid k "-1": expected a number above 0 up to 10`)
+}
+
+func Test_warmUp(t *testing.T) {
+	from := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, priceQuery{days: 33, period: api.Daily}, warmUp(priceQuery{days: 5, period: api.Daily}, 28))
+	assert.Equal(t, priceQuery{days: _maxDays, period: api.Daily}, warmUp(priceQuery{days: _maxDays, period: api.Daily}, 28))
+	assert.Equal(t, priceQuery{from: time.Date(2022, 3, 11, 0, 0, 0, 0, time.UTC), period: api.Daily}, warmUp(priceQuery{from: from, period: api.Daily}, 10))
+	assert.Equal(t, priceQuery{from: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), period: api.Monthly}, warmUp(priceQuery{from: from, period: api.Monthly}, 3))
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/api"
+)
+
+const (
+	_defaultBars = 100
+	_maxBars     = 5000
+
+	// _compactBars is the number of bars in a compact intraday response
+	_compactBars = 100
+
+	// the intraday series covers the extended trading hours of the exchange, from 4:00 to 20:00 New York time
+	_sessionOpen  = 4
+	_sessionClose = 20
+)
+
+// exchange is the time zone of the trading sessions
+var exchange = func() *time.Location {
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		return loc
+	}
+
+	return time.UTC
+}()
+
+// barQuery holds the validated values of a request for intraday bars
+type barQuery struct {
+	symbol   string
+	interval api.Interval
+	limit    int
+}
+
+// parseBarQuery validates the symbol and the interval and limit query parameters
+func parseBarQuery(symbol string, values url.Values) (barQuery, error) {
+	if !symbolRegex.MatchString(symbol) {
+		return barQuery{}, fmt.Errorf("invalid symbol %q", symbol)
+	}
+
+	q := barQuery{
+		symbol:   strings.ToUpper(symbol),
+		interval: api.FiveMinutes,
+		limit:    _defaultBars,
+	}
+
+	var err error
+
+	if interval := values.Get("interval"); interval != "" {
+		if q.interval, err = api.ParseInterval(interval); err != nil {
+			return barQuery{}, err
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		if q.limit, err = strconv.Atoi(limit); err != nil || q.limit < 1 || q.limit > _maxBars {
+			return barQuery{}, fmt.Errorf("invalid limit %q: expected a number between 1 and %d", limit, _maxBars)
+		}
+	}
+
+	return q, nil
+}
+
+// GetBars is a handler responsible for retrieving the latest intraday bars of a symbol
+func (h *handler) GetBars(ctx context.Context, w http.ResponseWriter, q barQuery) {
+	bars, err := h.getBars(ctx, q)
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Str("interval", string(q.interval)).Msg("get intraday bars")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	resp, err := json.Marshal(&api.OrderedIntraday{
+		Symbol:   q.symbol,
+		Interval: q.interval,
+		Bars:     bars,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("get intraday bars")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// getBars serves the cached bars while no newer bar can have been published since they were added, otherwise the
+// latest bars are fetched and cached first. Cached bars are served when the fetch fails.
+func (h *handler) getBars(ctx context.Context, q barQuery) ([]*api.IntradayBar, error) {
+	cached, refreshed, err := h.redis.GetBars(ctx, q.symbol, q.interval, q.limit)
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Msg("get cached bars")
+	}
+
+	if len(cached) != 0 && barsFresh(time.Now(), refreshed, q.interval) {
+		return cached, nil
+	}
+
+	size := api.Compact
+	if q.limit > _compactBars {
+		size = api.Full
+	}
+
+	if err = h.cacheBars(ctx, q.symbol, q.interval, size); err != nil {
+		if len(cached) != 0 {
+			log.Warn().Err(err).Str("symbol", q.symbol).Msg("serving stale bars")
+
+			return cached, nil
+		}
+
+		return nil, err
+	}
+
+	bars, _, err := h.redis.GetBars(ctx, q.symbol, q.interval, q.limit)
+
+	return bars, err
+}
+
+// cacheBars stores the latest bars of symbol at interval
+func (h *handler) cacheBars(ctx context.Context, symbol string, interval api.Interval, size api.OutputSize) error {
+	resp, err := h.apiClient.GetIntraday(ctx, symbol, interval, size)
+	if err != nil {
+		return err
+	}
+
+	bars, err := resp.Bars()
+	if err != nil {
+		return err
+	}
+
+	return h.redis.AddBars(ctx, symbol, interval, bars)
+}
+
+// barsFresh reports whether bars added at refreshed are still the latest at now: they were added less than one
+// interval ago, or the session closed before they were added and the next one has not opened yet. Holidays are
+// traded as usual days so the first request of the day fetches the bars once.
+func barsFresh(now, refreshed time.Time, interval api.Interval) bool {
+	if now.Sub(refreshed) < interval.Duration() {
+		return true
+	}
+
+	local := now.In(exchange)
+	if !isWeekend(local) && local.Hour() >= _sessionOpen && local.Hour() < _sessionClose {
+		return false
+	}
+
+	return !refreshed.Before(lastSessionClose(local))
+}
+
+// lastSessionClose returns the close of the latest weekday session that ended before t
+func lastSessionClose(t time.Time) time.Time {
+	closed := sessionClose(t)
+	if t.Before(closed) {
+		closed = sessionClose(t.AddDate(0, 0, -1))
+	}
+
+	for isWeekend(closed) {
+		closed = sessionClose(closed.AddDate(0, 0, -1))
+	}
+
+	return closed
+}
+
+// sessionClose returns the session close on the day of t, wall clock time so it holds across daylight saving changes
+func sessionClose(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), _sessionClose, 0, 0, 0, t.Location())
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/storage/mocks"
+)
+
+func Test_handler_GetBars(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	latest := time.Date(2022, 4, 1, 20, 0, 0, 0, time.UTC)
+
+	bars := []*api.IntradayBar{
+		{Timestamp: latest, Price: &api.Price{Open: api.MustParseDecimal("130.2000"), High: api.MustParseDecimal("130.2000"), Low: api.MustParseDecimal("130.1000"), Close: api.MustParseDecimal("130.1000"), Volume: 310}},
+		{Timestamp: latest.Add(-5 * time.Minute), Price: &api.Price{Open: api.MustParseDecimal("130.1500"), High: api.MustParseDecimal("130.1500"), Low: api.MustParseDecimal("130.1500"), Close: api.MustParseDecimal("130.1500"), Volume: 200}},
+	}
+
+	upstream := &api.IntradayResponse{
+		MetaData: api.IntradayMD{Symbol: "IBM", Interval: "5min", TimeZone: "UTC"},
+		Prices: map[string]api.Price{
+			"2022-04-01 20:00:00": *bars[0].Price,
+			"2022-04-01 19:55:00": *bars[1].Price,
+		},
+	}
+
+	expected, _ := json.Marshal(&api.OrderedIntraday{Symbol: "IBM", Interval: api.FiveMinutes, Bars: bars})
+
+	tests := []struct {
+		name                string
+		r                   *http.Request
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		apiMockOutcomes     func(apiMock *mock_api.MockAPI)
+		status              int
+		expected            string
+	}{
+		{
+			name: "serves bars cached less than an interval ago",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/ibm/bars?interval=5min&limit=2", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetBars(gomock.Any(), "IBM", api.FiveMinutes, 2).Times(1).Return(bars, time.Now(), nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {},
+			status:          http.StatusOK,
+			expected:        string(expected),
+		},
+		{
+			name: "fetches and caches the latest bars when the cache is stale",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/IBM/bars?limit=2", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				gomock.InOrder(
+					storageMock.EXPECT().GetBars(gomock.Any(), "IBM", api.FiveMinutes, 2).Times(1).Return(bars[1:], time.Now().AddDate(0, 0, -7), nil),
+					storageMock.EXPECT().AddBars(gomock.Any(), "IBM", api.FiveMinutes, bars).Times(1).Return(nil),
+					storageMock.EXPECT().GetBars(gomock.Any(), "IBM", api.FiveMinutes, 2).Times(1).Return(bars, time.Now(), nil),
+				)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().GetIntraday(gomock.Any(), "IBM", api.FiveMinutes, api.Compact).Times(1).Return(upstream, nil)
+			},
+			status:   http.StatusOK,
+			expected: string(expected),
+		},
+		{
+			name: "serves stale bars when the api fails",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/IBM/bars?limit=2", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetBars(gomock.Any(), "IBM", api.FiveMinutes, 2).Times(1).Return(bars, time.Now().AddDate(0, 0, -7), nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().GetIntraday(gomock.Any(), "IBM", api.FiveMinutes, api.Compact).Times(1).
+					Return(nil, &api.UpstreamError{Err: api.ErrRateLimited, Message: "5 calls per minute"})
+			},
+			status:   http.StatusOK,
+			expected: string(expected),
+		},
+		{
+			name: "fetches the full output for more than 100 bars",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/IBM/bars?interval=60min&limit=500", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetBars(gomock.Any(), "IBM", api.SixtyMinutes, 500).Times(1).Return(nil, time.Time{}, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().GetIntraday(gomock.Any(), "IBM", api.SixtyMinutes, api.Full).Times(1).
+					Return(nil, errors.New("test error"))
+			},
+			status:   http.StatusInternalServerError,
+			expected: errorBody(_errResponse),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+			tt.apiMockOutcomes(apiMock)
+
+			h := &handler{
+				apiClient: apiMock,
+				redis:     storageMock,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func Test_parseBarQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   url.Values
+		expected barQuery
+		err      string
+	}{
+		{
+			name:     "defaults",
+			values:   url.Values{},
+			expected: barQuery{symbol: "IBM", interval: api.FiveMinutes, limit: _defaultBars},
+		},
+		{
+			name:     "interval and limit",
+			values:   url.Values{"interval": {"1min"}, "limit": {"10"}},
+			expected: barQuery{symbol: "IBM", interval: api.OneMinute, limit: 10},
+		},
+		{
+			name:   "invalid interval",
+			values: url.Values{"interval": {"daily"}},
+			err:    `invalid interval "daily": expected one of 1min, 5min, 15min, 30min or 60min`,
+		},
+		{
+			name:   "invalid limit",
+			values: url.Values{"limit": {"-1"}},
+			err:    `invalid limit "-1": expected a number between 1 and 5000`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseBarQuery("ibm", tt.values)
+			if err != nil {
+				assert.Equal(t, tt.err, err.Error())
+
+				return
+			}
+
+			assert.Empty(t, tt.err)
+			assert.Equal(t, tt.expected, q)
+		})
+	}
+}
+
+func Test_barsFresh(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	// friday 1 April 2022
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2022, 4, day, hour, minute, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		refreshed time.Time
+		fresh     bool
+	}{
+		{name: "added less than an interval ago", now: at(1, 11, 0), refreshed: at(1, 10, 57), fresh: true},
+		{name: "added more than an interval ago during the session", now: at(1, 11, 0), refreshed: at(1, 10, 50), fresh: false},
+		{name: "added after the close the same evening", now: at(1, 23, 0), refreshed: at(1, 20, 30), fresh: true},
+		{name: "added before the close", now: at(1, 23, 0), refreshed: at(1, 19, 30), fresh: false},
+		{name: "added after the friday close over the weekend", now: at(3, 12, 0), refreshed: at(1, 21, 0), fresh: true},
+		{name: "added after the friday close before monday opens", now: at(4, 3, 0), refreshed: at(2, 9, 0), fresh: true},
+		{name: "added before the friday close over the weekend", now: at(2, 12, 0), refreshed: at(1, 15, 0), fresh: false},
+		{name: "added on friday once monday opened", now: at(4, 4, 30), refreshed: at(1, 21, 0), fresh: false},
+		{name: "added before the close of the previous day", now: at(1, 2, 0), refreshed: time.Date(2022, 3, 31, 19, 0, 0, 0, newYork), fresh: false},
+		{name: "added after the close of the previous day", now: at(1, 2, 0), refreshed: time.Date(2022, 3, 31, 21, 0, 0, 0, newYork), fresh: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.fresh, barsFresh(tt.now, tt.refreshed, api.FiveMinutes))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+
+	"stock_ticker/api"
+)
+
+const (
+	// _currency is the currency prices are reported in, the api quotes the US listings the service tracks in dollars
+	// and its daily series do not state a currency
+	_currency = "USD"
+
+	// _sourceCache is the source of prices read from the cache, others are named after their provider
+	_sourceCache = "cache"
+)
+
+// pricesV2 is the v2 response of the prices of a symbol. Unlike api.OrderedResponse it does not carry the field names
+// of the upstream api so it stays the same whichever series the prices were read from.
+type pricesV2 struct {
+	Symbol   string    `json:"symbol"`
+	Currency string    `json:"currency"`
+	AsOf     string    `json:"as_of"`
+	Source   string    `json:"source"`
+	Interval string    `json:"interval"`
+	Adjusted bool      `json:"adjusted"`
+	Bars     []barV2   `json:"bars"`
+	Summary  summaryV2 `json:"summary"`
+}
+
+// barV2 is the OHLCV of a day or a period keyed by its last day. Prices are numbers with the decimal places they
+// were quoted with.
+type barV2 struct {
+	Date   string      `json:"date"`
+	Open   json.Number `json:"open"`
+	High   json.Number `json:"high"`
+	Low    json.Number `json:"low"`
+	Close  json.Number `json:"close"`
+	Volume int64       `json:"volume"`
+}
+
+// summaryV2 describes the bars of a response, the price fields are left out when there are no bars
+type summaryV2 struct {
+	Count         int         `json:"count"`
+	AverageClose  float64     `json:"average_close"`
+	High          json.Number `json:"high,omitempty"`
+	Low           json.Number `json:"low,omitempty"`
+	Change        json.Number `json:"change,omitempty"`
+	ChangePercent float64     `json:"change_percent"`
+	TotalVolume   int64       `json:"total_volume"`
+}
+
+// newPricesV2 converts prices, latest first, into the v2 response
+func newPricesV2(q priceQuery, prices *api.OrderedResponse, source, asOf string) pricesV2 {
+	resp := pricesV2{
+		Symbol:   q.symbol,
+		Currency: _currency,
+		AsOf:     asOf,
+		Source:   source,
+		Interval: string(q.period),
+		Adjusted: q.adjusted,
+		Bars:     make([]barV2, 0, len(prices.DailyPrices)),
+		Summary: summaryV2{
+			Count:        len(prices.DailyPrices),
+			AverageClose: prices.AvgClosingPrice,
+		},
+	}
+
+	if len(prices.DailyPrices) == 0 {
+		return resp
+	}
+
+	high, low := prices.DailyPrices[0].Price.High, prices.DailyPrices[0].Price.Low
+
+	for _, price := range prices.DailyPrices {
+		resp.Bars = append(resp.Bars, barV2{
+			Date:   price.Day,
+			Open:   json.Number(price.Price.Open.String()),
+			High:   json.Number(price.Price.High.String()),
+			Low:    json.Number(price.Price.Low.String()),
+			Close:  json.Number(price.Price.Close.String()),
+			Volume: price.Price.Volume,
+		})
+
+		if price.Price.High.Cmp(high) > 0 {
+			high = price.Price.High
+		}
+
+		if price.Price.Low.Cmp(low) < 0 {
+			low = price.Price.Low
+		}
+
+		resp.Summary.TotalVolume += price.Price.Volume
+	}
+
+	first, last := prices.DailyPrices[len(prices.DailyPrices)-1].Price.Close, prices.DailyPrices[0].Price.Close
+	change := last.Sub(first)
+
+	resp.Summary.High = json.Number(high.String())
+	resp.Summary.Low = json.Number(low.String())
+	resp.Summary.Change = json.Number(change.String())
+
+	if !first.IsZero() {
+		resp.Summary.ChangePercent = api.FixedPrecision(change.Float64()/first.Float64()*100, 2)
+	}
+
+	if resp.AsOf == "" {
+		resp.AsOf = prices.DailyPrices[0].Day
+	}
+
+	return resp
+}
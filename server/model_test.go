@@ -0,0 +1,18 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+)
+
+func Test_newPricesV2_noPrices(t *testing.T) {
+	q := priceQuery{symbol: "MSFT", days: 5, period: api.Weekly, adjusted: true}
+
+	resp, err := json.Marshal(newPricesV2(q, &api.OrderedResponse{}, _sourceCache, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"symbol":"MSFT","currency":"USD","as_of":"","source":"cache","interval":"weekly","adjusted":true,"bars":[],"summary":{"count":0,"average_close":0,"change_percent":0,"total_volume":0}}`, string(resp))
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"stock_ticker/reconcile"
+)
+
+const (
+	_errNoReport      = "no data quality report yet"
+	_errNoReconciling = "prices are not reconciled, no fallback provider is configured"
+)
+
+// qualityReport holds the latest data quality report
+type qualityReport interface {
+	Report() *reconcile.Report
+}
+
+type qualityHandler struct {
+	reports qualityReport
+}
+
+// NewQualityHandler serves the reports of reports, nil when prices are not reconciled
+func NewQualityHandler(reports qualityReport) qualityHandler {
+	return qualityHandler{
+		reports: reports,
+	}
+}
+
+// Get is a handler responsible for the latest data quality report
+//
+//	GET /v1/quality
+func (h *qualityHandler) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, _errMethod)
+
+		return
+	}
+
+	if h.reports == nil {
+		writeError(w, http.StatusNotFound, _errNoReconciling)
+
+		return
+	}
+
+	report := h.reports.Report()
+	if report == nil {
+		writeError(w, http.StatusNotFound, _errNoReport)
+
+		return
+	}
+
+	resp, err := json.Marshal(report)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
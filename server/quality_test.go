@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/reconcile"
+)
+
+type fakeReports struct {
+	report *reconcile.Report
+}
+
+func (f fakeReports) Report() *reconcile.Report {
+	return f.report
+}
+
+func Test_qualityHandler_Get(t *testing.T) {
+	report := &reconcile.Report{
+		Generated:       time.Date(2022, 4, 1, 17, 30, 0, 0, time.UTC),
+		PriceTolerance:  reconcile.DefaultPriceTolerance,
+		VolumeTolerance: reconcile.DefaultVolumeTolerance,
+		Symbols: []reconcile.SymbolReport{
+			{Symbol: "IBM", Primary: "alpha_vantage", Secondary: "csv", From: "2022-04-01", To: "2022-04-01", Compared: 1, Discrepancies: []reconcile.Discrepancy{
+				{Day: "2022-04-01", Field: "close", Primary: 130.15, Secondary: 131.5, Difference: 0.010373},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		reports  qualityReport
+		method   string
+		status   int
+		expected string
+	}{
+		{
+			name:     "latest report",
+			reports:  fakeReports{report: report},
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			expected: `{"generated":"2022-04-01T17:30:00Z","price_tolerance":0.005,"volume_tolerance":0.1,"symbols":[{"symbol":"IBM","primary":"alpha_vantage","secondary":"csv","from":"2022-04-01","to":"2022-04-01","compared":1,"discrepancies":[{"day":"2022-04-01","field":"close","primary":130.15,"secondary":131.5,"difference":0.010373}]}]}`,
+		},
+		{
+			name:     "before the first reconciliation",
+			reports:  fakeReports{},
+			method:   http.MethodGet,
+			status:   http.StatusNotFound,
+			expected: errorBody(_errNoReport),
+		},
+		{
+			name:     "not reconciling",
+			method:   http.MethodGet,
+			status:   http.StatusNotFound,
+			expected: errorBody(_errNoReconciling),
+		},
+		{
+			name:     "method not allowed",
+			reports:  fakeReports{report: report},
+			method:   http.MethodPost,
+			status:   http.StatusMethodNotAllowed,
+			expected: errorBody(_errMethod),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewQualityHandler(tt.reports)
+
+			w := httptest.NewRecorder()
+			h.Get(w, httptest.NewRequest(tt.method, "/v1/quality", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
@@ -3,34 +3,123 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/rs/zerolog/hlog"
-	"github.com/rs/zerolog/log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"stock_ticker/api"
 	"stock_ticker/storage"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/rs/zerolog/hlog"
+	"github.com/rs/zerolog/log"
 )
 
 const (
 	_errCache    = "error initializes daily prices cache"
 	_errResponse = "error retrieving stock price data"
+	_errNotFound = "route not found"
+	_errMethod   = "method not allowed"
+	_errNoQuota  = "quota is not managed"
+	_errTimeout  = "request timed out"
+	_errCanceled = "request canceled"
+
+	// _statusClientClosedRequest is reported when the client went away before the response was written
+	_statusClientClosedRequest = 499
+
+	// DefaultTimeout is how long serving a request may take
+	DefaultTimeout = 100 * time.Second
+
+	_v1SymbolsPrefix = "/v1/symbols/"
+	_v2SymbolsPrefix = "/v2/symbols/"
+
+	// _compactDays is the number of days a compact series holds
+	_compactDays = 100
+
+	// _rangeSlackDays is how many days the first trading day of a range may follow its start, a weekend and a holiday
+	_rangeSlackDays = 3
+
+	// _maxDays is 20 years of calendar days, the full history the api provides
+	_maxDays = 20 * 366
 )
 
+// errNotAdjusted is returned for adjusted prices when no corporate actions are stored to adjust them with
+var errNotAdjusted = errors.New("adjusted prices are not served, corporate actions are only stored with ADJUSTED=true")
+
+var symbolRegex = regexp.MustCompile(`^[A-Za-z0-9.\-]{1,12}$`)
+
 type handler struct {
 	apiClient api.API
+	// providers select the provider of the daily prices of each symbol, apiClient provides every symbol when nil
+	providers *api.Providers
 	redis     storage.Storage
+	symbol    string
 	nDays     int
+	// timeout bounds every request on top of the client going away, zero leaving it to the client
+	timeout time.Duration
+	// adjusted is whether corporate actions are stored so adjusted prices can be served
+	adjusted bool
+}
+
+// priceQuery holds the validated values of a request for prices
+type priceQuery struct {
+	symbol   string
+	days     int
+	from     time.Time
+	to       time.Time
+	adjusted bool
+	period   api.Period
 }
 
-func NewHandler(client api.API, redisClient storage.Storage, days int) handler {
+// isRange reports whether the query is bounded by dates rather than just a number of days
+func (q priceQuery) isRange() bool {
+	return !q.from.IsZero() || !q.to.IsZero()
+}
+
+// window returns the query for the daily prices the bars of the query are resampled from. The days of a resampled
+// query count bars so the window is a range long enough to hold them.
+func (q priceQuery) window(now time.Time) priceQuery {
+	if q.period == api.Daily {
+		return q
+	}
+
+	window := q
+	window.days = 0
+
+	if !q.isRange() {
+		window.from = q.period.Start(now, q.days)
+	}
+
+	return window
+}
+
+func NewHandler(client api.API, providers *api.Providers, redisClient storage.Storage, symbol string, days int,
+	timeout time.Duration, adjusted bool) handler {
 	return handler{
 		apiClient: client,
+		providers: providers,
 		redis:     redisClient,
+		symbol:    symbol,
 		nDays:     days,
+		timeout:   timeout,
+		adjusted:  adjusted,
 	}
 }
 
+// ServeHTTP routes requests to the handlers. The storage and api calls of a request are made within its context so
+// the client going away, or the request outliving the timeout, cancels those still in flight.
+//
+//	GET /                                   last NDAYS of SYMBOL as configured at startup
+//	GET /v1/symbols/{symbol}/prices?days=N&from=YYYY-MM-DD&to=YYYY-MM-DD&adjusted=true&interval=weekly
+//	GET /v1/symbols/{symbol}/bars?interval=5min&limit=N
+//	GET /v1/symbols/{symbol}/indicators/{name}?period=N and the prices query parameters
+//	GET /v1/symbols/{symbol}/statistics?risk_free=0.02 and the prices query parameters
+//	GET /v1/compare?symbols=IBM,MSFT&benchmark=SPY and the prices query parameters
+//	GET /v1/quota                           api quota remaining
+//	GET /v2/symbols/{symbol}/prices         as /v1 with the v2 response model
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	hlog.FromRequest(r).Info().
 		Str("status", "ok").
@@ -38,73 +127,506 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, _errMethod)
+
+		return
+	}
+
+	ctx, cancel := h.context(r)
+	defer cancel()
+
 	switch {
-	case r.Method == http.MethodGet:
-		h.Get(w)
+	case r.URL.Path == "/":
+		h.Get(ctx, w, priceQuery{symbol: h.symbol, days: h.nDays, period: api.Daily})
+	case strings.HasPrefix(r.URL.Path, _v1SymbolsPrefix):
+		h.serveSymbol(ctx, w, r)
+	case strings.HasPrefix(r.URL.Path, _v2SymbolsPrefix):
+		h.serveSymbolV2(ctx, w, r)
+	case r.URL.Path == "/v1/compare":
+		q, err := parseCompareQuery(r.URL.Query(), h.nDays)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		h.GetComparison(ctx, w, q)
+	case r.URL.Path == "/v1/quota":
+		h.GetQuota(ctx, w)
+	default:
+		writeError(w, http.StatusNotFound, _errNotFound)
+	}
+}
+
+// context returns the context the calls made to serve r are made within
+func (h *handler) context(r *http.Request) (context.Context, context.CancelFunc) {
+	if h.timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+
+	return context.WithTimeout(r.Context(), h.timeout)
+}
+
+// serveSymbol routes the requests under /v1/symbols/{symbol}/
+func (h *handler) serveSymbol(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, _v1SymbolsPrefix), "/")
+	if len(parts) == 3 && parts[1] == "indicators" {
+		q, err := parseIndicatorQuery(parts[0], parts[2], r.URL.Query(), h.nDays)
+		if err != nil {
+			writeIndicatorError(w, err)
+
+			return
+		}
+
+		h.GetIndicator(ctx, w, q)
+
+		return
+	}
+
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, _errNotFound)
+
 		return
 	}
 
+	symbol, resource := parts[0], parts[1]
+
+	switch resource {
+	case "prices":
+		q, err := parsePriceQuery(symbol, r.URL.Query(), h.nDays)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		h.Get(ctx, w, q)
+	case "statistics":
+		q, err := parseStatisticsQuery(symbol, r.URL.Query(), h.nDays)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		h.GetStatistics(ctx, w, q)
+	case "bars":
+		q, err := parseBarQuery(symbol, r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		h.GetBars(ctx, w, q)
+	default:
+		writeError(w, http.StatusNotFound, _errNotFound)
+	}
 }
 
-// Get is a handler responsible for retrieving the last NDAYS of data
-func (h *handler) Get(w http.ResponseWriter) {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
+// serveSymbolV2 routes the requests under /v2/symbols/{symbol}/
+func (h *handler) serveSymbolV2(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, _v2SymbolsPrefix), "/")
+	if len(parts) != 2 || parts[1] != "prices" {
+		writeError(w, http.StatusNotFound, _errNotFound)
+
+		return
+	}
+
+	q, err := parsePriceQuery(parts[0], r.URL.Query(), h.nDays)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 
-	//TODO check if NDAYS greater than 20 years of stock prices
+		return
+	}
+
+	h.GetV2(ctx, w, q)
+}
+
+// parsePriceQuery validates the symbol and the days, from, to, adjusted and interval query parameters. When no range
+// is given the last defaultDays are returned, days count bars of the interval when it is not daily.
+func parsePriceQuery(symbol string, values url.Values, defaultDays int) (priceQuery, error) {
+	if !symbolRegex.MatchString(symbol) {
+		return priceQuery{}, fmt.Errorf("invalid symbol %q", symbol)
+	}
+
+	q := priceQuery{symbol: strings.ToUpper(symbol), period: api.Daily}
+
+	var err error
 
-	var dailyPrices *api.OrderedResponse
+	if interval := values.Get("interval"); interval != "" {
+		if q.period, err = api.ParsePeriod(interval); err != nil {
+			return priceQuery{}, err
+		}
+	}
+
+	if from := values.Get("from"); from != "" {
+		if q.from, err = time.Parse(api.Format, from); err != nil {
+			return priceQuery{}, fmt.Errorf("invalid from %q: expected YYYY-MM-DD", from)
+		}
+	}
+
+	if to := values.Get("to"); to != "" {
+		if q.to, err = time.Parse(api.Format, to); err != nil {
+			return priceQuery{}, fmt.Errorf("invalid to %q: expected YYYY-MM-DD", to)
+		}
+	}
+
+	if !q.from.IsZero() && !q.to.IsZero() && q.from.After(q.to) {
+		return priceQuery{}, errors.New("from must not be after to")
+	}
+
+	if adjusted := values.Get("adjusted"); adjusted != "" {
+		if q.adjusted, err = strconv.ParseBool(adjusted); err != nil {
+			return priceQuery{}, fmt.Errorf("invalid adjusted %q: expected true or false", adjusted)
+		}
+	}
+
+	days := values.Get("days")
+
+	switch {
+	case days != "":
+		if q.days, err = strconv.Atoi(days); err != nil || q.days < 1 || q.days > _maxDays {
+			return priceQuery{}, fmt.Errorf("invalid days %q: expected a number between 1 and %d", days, _maxDays)
+		}
+	case !q.isRange():
+		q.days = defaultDays
+	}
+
+	return q, nil
+}
+
+// Get is a handler responsible for retrieving the prices matching the query
+func (h *handler) Get(ctx context.Context, w http.ResponseWriter, q priceQuery) {
+	prices, _, err := h.prices(ctx, q)
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Msg("get apiClient prices")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	resp, err := json.Marshal(prices)
+	if err != nil {
+		log.Error().Err(err).Msg("get apiClient prices")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+
+}
+
+// prices returns the prices matching the query along with where they were read from, adjusted and resampled as asked
+func (h *handler) prices(ctx context.Context, q priceQuery) (*api.OrderedResponse, string, error) {
+	if q.adjusted && !h.adjusted {
+		return nil, "", errNotAdjusted
+	}
+
+	prices, source, err := h.getPrices(ctx, q.window(time.Now()))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if q.adjusted {
+		if prices, err = h.adjust(ctx, q.symbol, prices); err != nil {
+			return nil, "", fmt.Errorf("adjust prices: %w", err)
+		}
+	}
+
+	if q.period != api.Daily {
+		if prices, err = resample(prices, q); err != nil {
+			return nil, "", fmt.Errorf("resample prices: %w", err)
+		}
+	}
+
+	return prices, source, nil
+}
+
+// GetQuota is a handler responsible for reporting the api quota remaining
+func (h *handler) GetQuota(ctx context.Context, w http.ResponseWriter) {
+	quota := h.apiClient.Quota()
+	if quota == nil {
+		writeError(w, http.StatusNotFound, _errNoQuota)
+
+		return
+	}
+
+	status, err := quota.Remaining(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("get quota")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	resp, err := json.Marshal(status)
+	if err != nil {
+		log.Error().Err(err).Msg("get quota")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// getPrices tries the cache first and falls back to the api. A range the cache cannot answer is filled by caching
+// the full history of the symbol, or read from the weekly or monthly series of the api when those are asked for.
+func (h *handler) getPrices(ctx context.Context, q priceQuery) (*api.OrderedResponse, string, error) {
+	var prices []*api.DailyPrice
+	var avgClose float64
+	var err error
 
 	// try retrieving data from the cache
-	prices, avgClose, err := h.redis.GetPriceInfo(h.nDays)
+	if q.isRange() {
+		prices, avgClose, err = h.redis.GetPriceRange(ctx, q.symbol, q.from, q.to, q.days)
+	} else {
+		prices, avgClose, err = h.redis.GetPriceInfo(ctx, q.symbol, q.days)
+	}
+
 	if err != nil {
-		log.Error().Err(err).Msg("get cached prices")
+		log.Error().Err(err).Str("symbol", q.symbol).Msg("get cached prices")
 	}
 
-	// check if data is in cache
-	if len(prices) != 0 && avgClose != 0 {
-		dailyPrices = &api.OrderedResponse{
+	// check the cache holds every day asked for, not just some of them
+	if len(prices) != 0 && avgClose != 0 && covers(q, prices) {
+		return &api.OrderedResponse{
 			DailyPrices:     prices,
 			AvgClosingPrice: avgClose,
+		}, _sourceCache, nil
+	}
+
+	// call the provider of the symbol to get the data as a fallback
+	if !q.isRange() {
+		size := api.Compact
+		if q.days > _compactDays {
+			size = api.Full
 		}
-	} else { // call the api to get the data as a fallback
-		dailyPrices, err = h.apiClient.GetPrices(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("get apiClient prices")
-			w.WriteHeader(http.StatusInternalServerError)
 
-			w.Write([]byte(fmt.Sprintf("error: %v", _errResponse)))
+		series, err := h.provider(q.symbol).Daily(ctx, q.symbol, size)
+		if err != nil {
+			return nil, "", err
+		}
 
-			return
+		return series.Latest(q.days), series.Source, nil
+	}
 
+	// only the api serves bars of a period, the daily prices of the provider of the symbol are resampled when it fails
+	if q.period != api.Daily && q.period.Upstream() {
+		resp, err := h.getPeriodSeries(ctx, q)
+		if err == nil || ctx.Err() != nil {
+			return resp, api.AlphaVantage, err
 		}
+
+		log.Warn().Err(err).
+			Str("symbol", q.symbol).
+			Str("period", string(q.period)).
+			Msg("period series failed, resampling daily prices")
 	}
 
-	resp, err := json.Marshal(dailyPrices)
+	source, err := h.cache(ctx, q.symbol)
 	if err != nil {
-		log.Error().Err(err).Msg("get apiClient prices")
+		return nil, "", err
+	}
+
+	prices, avgClose, err = h.redis.GetPriceRange(ctx, q.symbol, q.from, q.to, q.days)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &api.OrderedResponse{
+		DailyPrices:     prices,
+		AvgClosingPrice: avgClose,
+	}, source, nil
+}
 
-		w.WriteHeader(http.StatusInternalServerError)
+// covers reports whether the cached prices, latest first, answer the whole query: as many days as were asked for, or
+// prices from the first trading day of the range on. That day may follow from by a weekend and a holiday.
+func covers(q priceQuery, prices []*api.DailyPrice) bool {
+	if q.days > 0 && len(prices) >= q.days {
+		return true
+	}
 
-		w.Write([]byte(fmt.Sprintf("error: %v", _errResponse)))
+	if !q.isRange() {
+		return false
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if q.from.IsZero() {
+		return true
+	}
+
+	oldest, err := time.Parse(api.Format, prices[len(prices)-1].Day)
+	if err != nil {
+		return false
+	}
+
+	return !oldest.After(q.from.AddDate(0, 0, _rangeSlackDays))
+}
+
+// getPeriodSeries gets the prices of the range from the api series of the query period, bars are not cached as the
+// cache holds daily prices only
+func (h *handler) getPeriodSeries(ctx context.Context, q priceQuery) (*api.OrderedResponse, error) {
+	resp, err := h.apiClient.GetPeriodSeries(ctx, q.symbol, q.period)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := api.Ordered(resp.DailyPrices, q.from, q.to)
+
+	return &api.OrderedResponse{
+		DailyPrices:     prices,
+		AvgClosingPrice: api.AverageClose(prices),
+	}, nil
+}
+
+// resample rolls the daily prices into bars of the query period, keeping the latest days bars
+func resample(prices *api.OrderedResponse, q priceQuery) (*api.OrderedResponse, error) {
+	bars, err := api.Resample(prices.DailyPrices, q.period)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.days > 0 && len(bars) > q.days {
+		bars = bars[:q.days]
+	}
+
+	return &api.OrderedResponse{
+		DailyPrices:     bars,
+		AvgClosingPrice: api.AverageClose(bars),
+	}, nil
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// adjust adjusts the prices for the dividends and splits stored for the symbol
+func (h *handler) adjust(ctx context.Context, symbol string, prices *api.OrderedResponse) (*api.OrderedResponse, error) {
+	if len(prices.DailyPrices) == 0 {
+		return prices, nil
+	}
+
+	// prices are latest first so every action from the earliest day on is needed
+	from, err := time.Parse(api.Format, prices.DailyPrices[len(prices.DailyPrices)-1].Day)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := h.redis.GetCorporateActions(ctx, symbol, from)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted := api.Adjust(prices.DailyPrices, actions)
+
+	return &api.OrderedResponse{
+		DailyPrices:     adjusted,
+		AvgClosingPrice: api.AverageClose(adjusted),
+	}, nil
+}
+
+// writeError writes the status code and error message
+func writeError(w http.ResponseWriter, status int, msg string) {
+	resp, _ := json.Marshal(errorResponse{Error: msg})
+
+	w.WriteHeader(status)
+
 	w.Write(resp)
+}
 
+// writeUpstreamError maps errors reported by the api to a status code, anything else is an internal error
+//
+//	rate limited, daily quota exhausted -> 429
+//	adjusted prices are not served      -> 400
+//	invalid symbol                      -> 404
+//	invalid api key, other api errors   -> 502
+//	api or request timed out            -> 504
+//	client went away                    -> 499
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotAdjusted):
+		writeError(w, http.StatusBadRequest, errNotAdjusted.Error())
+	case errors.Is(err, context.Canceled):
+		writeError(w, _statusClientClosedRequest, _errCanceled)
+	case errors.Is(err, api.ErrTimeout):
+		writeError(w, http.StatusGatewayTimeout, api.ErrTimeout.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, _errTimeout)
+	case errors.Is(err, api.ErrRateLimited):
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusTooManyRequests, api.ErrRateLimited.Error())
+	case errors.Is(err, api.ErrQuotaExceeded):
+		writeError(w, http.StatusTooManyRequests, api.ErrQuotaExceeded.Error())
+	case errors.Is(err, api.ErrInvalidSymbol):
+		writeError(w, http.StatusNotFound, api.ErrInvalidSymbol.Error())
+	case errors.Is(err, api.ErrInvalidAPIKey), errors.Is(err, api.ErrUpstream):
+		writeError(w, http.StatusBadGateway, api.ErrUpstream.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, _errResponse)
+	}
 }
 
-// CacheData TODO: the api returns the field \Last Refreshed\, this should be checked before caching the data
-// CacheData data calls the getFullPriceHistory and stores data to redis to be used when a requests ask for data
-func (h *handler) CacheData(ctx context.Context) error {
-	resp, err := h.apiClient.GetAllPrices(ctx)
+// cache stores the full price history of symbol and returns the name of the provider it came from
+func (h *handler) cache(ctx context.Context, symbol string) (string, error) {
+	series, err := h.provider(symbol).Daily(ctx, symbol, api.Full)
 	if err != nil {
-		return fmt.Errorf(_errCache+"%e", err)
+		return "", fmt.Errorf("%s: %w", _errCache, err)
 	}
 
-	if err = h.redis.AddPrices(resp); err != nil {
-		return fmt.Errorf(_errCache+"%e", err)
+	if err = h.redis.AddPrices(ctx, symbol, series.Response()); err != nil {
+		return "", fmt.Errorf("%s: %w", _errCache, err)
+	}
+
+	return series.Source, nil
+}
+
+// provider returns the provider of the daily prices of symbol
+func (h *handler) provider(symbol string) api.Provider {
+	if h.providers == nil {
+		return h.apiClient
 	}
 
-	return err
+	return h.providers.For(symbol)
+}
+
+// GetV2 is a handler responsible for retrieving the prices matching the query in the v2 response model
+func (h *handler) GetV2(ctx context.Context, w http.ResponseWriter, q priceQuery) {
+	prices, source, err := h.prices(ctx, q)
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Msg("get apiClient prices")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	var asOf string
+
+	if source == _sourceCache {
+		if asOf, err = h.redis.GetLastRefreshed(ctx, q.symbol); err != nil {
+			log.Warn().Err(err).Str("symbol", q.symbol).Msg("get last refreshed")
+		}
+	}
+
+	resp, err := json.Marshal(newPricesV2(q, prices, source, asOf))
+	if err != nil {
+		log.Error().Err(err).Msg("get apiClient prices")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,12 +9,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"stock_ticker/api"
 	"stock_ticker/api/mocks"
+	"stock_ticker/apitest/fake"
 	"stock_ticker/storage/mocks"
 )
 
@@ -26,44 +31,40 @@ func Test_handler_ServeHTTP(t *testing.T) {
 
 	defer mockController.Finish()
 
+	symbol := "MSFT"
 	days := 3
 
 	stringResponse := `{"Daily Price":[{"Day":"2022-04-01","Time Series (Daily)":{"1. open":"309.3700","2. high":"310.1300","3. low":"305.5400","4. close":"309.4200","5. volume":"27110529"}},{"Day":"2022-03-31","Time Series (Daily)":{"1. open":"313.9000","2. high":"315.1400","3. low":"307.8900","4. close":"308.3100","5. volume":"33422070"}},{"Day":"2022-03-30","Time Series (Daily)":{"1. open":"313.7600","2. high":"315.9500","3. low":"311.5800","4. close":"313.8600","5. volume":"28163555"}}],"Average Closing Price":313.21}`
 
-	var apiResponse api.OrderedResponse
-	if err := json.Unmarshal([]byte(stringResponse), &apiResponse); err != nil {
-		t.Errorf("test error :%e", err)
-	}
-
 	StockPrices := []*api.DailyPrice{
 		{
 			Day: "2022-04-01",
 			Price: &api.Price{
-				Open:   "309.3700",
-				High:   "310.1300",
-				Low:    "305.5400",
-				Close:  "309.4200",
-				Volume: "27110529",
+				Open:   api.MustParseDecimal("309.3700"),
+				High:   api.MustParseDecimal("310.1300"),
+				Low:    api.MustParseDecimal("305.5400"),
+				Close:  api.MustParseDecimal("309.4200"),
+				Volume: 27110529,
 			},
 		},
 		{
 			Day: "2022-03-31",
 			Price: &api.Price{
-				Open:   "313.9000",
-				High:   "315.1400",
-				Low:    "307.8900",
-				Close:  "308.3100",
-				Volume: "33422070",
+				Open:   api.MustParseDecimal("313.9000"),
+				High:   api.MustParseDecimal("315.1400"),
+				Low:    api.MustParseDecimal("307.8900"),
+				Close:  api.MustParseDecimal("308.3100"),
+				Volume: 33422070,
 			},
 		},
 		{
 			Day: "2022-03-30",
 			Price: &api.Price{
-				Open:   "313.7600",
-				High:   "315.9500",
-				Low:    "311.5800",
-				Close:  "313.8600",
-				Volume: "28163555",
+				Open:   api.MustParseDecimal("313.7600"),
+				High:   api.MustParseDecimal("315.9500"),
+				Low:    api.MustParseDecimal("311.5800"),
+				Close:  api.MustParseDecimal("313.8600"),
+				Volume: 28163555,
 			},
 		},
 	}
@@ -82,7 +83,7 @@ func Test_handler_ServeHTTP(t *testing.T) {
 			r:    httptest.NewRequest(http.MethodGet, "/", nil),
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
 				storageMock.EXPECT().
-					GetPriceInfo(days).
+					GetPriceInfo(gomock.Any(), symbol, days).
 					Times(1).
 					Return(StockPrices, 313.21, nil)
 			},
@@ -96,17 +97,17 @@ func Test_handler_ServeHTTP(t *testing.T) {
 			r:    httptest.NewRequest(http.MethodGet, "/", nil),
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
 				storageMock.EXPECT().
-					GetPriceInfo(days).
+					GetPriceInfo(gomock.Any(), symbol, days).
 					Times(1).
 					Return([]*api.DailyPrice{}, float64(0), nil)
 			},
 			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
 				apiMock.EXPECT().
-					GetPrices(gomock.Any()). // contexts will be different each time
+					Daily(gomock.Any(), symbol, api.Compact). // contexts will be different each time
 					Times(1).
-					Return(&apiResponse, nil)
+					Return(&api.Series{Symbol: symbol, Source: api.AlphaVantage, Prices: StockPrices}, nil)
 			},
-			expected: stringResponse,
+			expected: strings.Replace(stringResponse, "313.21", "310.53", 1),
 		},
 		{
 			name: "returns an error",
@@ -114,17 +115,323 @@ func Test_handler_ServeHTTP(t *testing.T) {
 			r:    httptest.NewRequest(http.MethodGet, "/", nil),
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
 				storageMock.EXPECT().
-					GetPriceInfo(days).
+					GetPriceInfo(gomock.Any(), symbol, days).
 					Times(1).
 					Return([]*api.DailyPrice{}, float64(0), nil)
 			},
 			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
 				apiMock.EXPECT().
-					GetPrices(gomock.Any()). // contexts will be different each time
+					Daily(gomock.Any(), symbol, api.Compact). // contexts will be different each time
 					Times(1).
 					Return(nil, errors.New("test error"))
 			},
-			expected: fmt.Sprintf("error: %v", _errResponse),
+			expected: errorBody(_errResponse),
+		},
+		{
+			name: "get stock prices for the symbol and days in the request",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/ibm/prices?days=2", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), "IBM", 2).
+					Times(1).
+					Return(StockPrices, 313.21, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: stringResponse,
+		},
+		{
+			name: "get stock prices for a date range",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?from=2022-03-30&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceRange(gomock.Any(), symbol, time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), 0).
+					Times(1).
+					Return(StockPrices, 313.21, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: stringResponse,
+		},
+		{
+			name: "date range not cached so caches the full history",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?from=2022-03-30", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				from := time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC)
+
+				gomock.InOrder(
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, time.Time{}, 0).
+						Times(1).
+						Return([]*api.DailyPrice{}, float64(0), nil),
+					storageMock.EXPECT().
+						AddPrices(gomock.Any(), symbol, gomock.Any()).
+						Times(1).
+						Return(nil),
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, time.Time{}, 0).
+						Times(1).
+						Return(StockPrices, 313.21, nil),
+				)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Full).
+					Times(1).
+					Return(&api.Series{Symbol: symbol, Source: api.AlphaVantage}, nil)
+			},
+			expected: stringResponse,
+		},
+		{
+			name: "fewer days cached than asked for so calls the api",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), symbol, days).
+					Times(1).
+					Return(StockPrices[:2], 308.87, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Compact).
+					Times(1).
+					Return(&api.Series{Symbol: symbol, Source: api.AlphaVantage, Prices: StockPrices}, nil)
+			},
+			expected: strings.Replace(stringResponse, "313.21", "310.53", 1),
+		},
+		{
+			name: "date range cached from later than its start so caches the full history",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?from=2022-03-24&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				from, to := time.Date(2022, 3, 24, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+				gomock.InOrder(
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, to, 0).
+						Times(1).
+						Return(StockPrices, 313.21, nil),
+					storageMock.EXPECT().
+						AddPrices(gomock.Any(), symbol, gomock.Any()).
+						Times(1).
+						Return(nil),
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, to, 0).
+						Times(1).
+						Return(StockPrices, 313.21, nil),
+				)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Full).
+					Times(1).
+					Return(&api.Series{Symbol: symbol, Source: api.AlphaVantage}, nil)
+			},
+			expected: stringResponse,
+		},
+		{
+			name: "date range starting on a weekend served from the cache",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?from=2022-03-27&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceRange(gomock.Any(), symbol, time.Date(2022, 3, 27, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), 0).
+					Times(1).
+					Return(append(StockPrices, &api.DailyPrice{Day: "2022-03-28", Price: StockPrices[2].Price}), 313.21, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: strings.Replace(stringResponse, `],"Average`, `,{"Day":"2022-03-28","Time Series (Daily)":{"1. open":"313.7600","2. high":"315.9500","3. low":"311.5800","4. close":"313.8600","5. volume":"28163555"}}],"Average`, 1),
+		},
+		{
+			name: "throttled by the api",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), symbol, days).
+					Times(1).
+					Return([]*api.DailyPrice{}, float64(0), nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Compact).
+					Times(1).
+					Return(nil, &api.UpstreamError{Err: api.ErrRateLimited, Message: "5 calls per minute"})
+			},
+			expected: errorBody(api.ErrRateLimited.Error()),
+		},
+		{
+			name: "get stock prices adjusted for dividends and splits",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?days=3&adjusted=true", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), symbol, days).
+					Times(1).
+					Return(StockPrices, 313.21, nil)
+				storageMock.EXPECT().
+					GetCorporateActions(gomock.Any(), symbol, time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC)).
+					Times(1).
+					Return(api.CorporateActions{"2022-03-31": {SplitCoefficient: api.MustParseDecimal("2.0"), Factor: 0.5}}, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: `{"Daily Price":[{"Day":"2022-04-01","Time Series (Daily)":{"1. open":"309.3700","2. high":"310.1300","3. low":"305.5400","4. close":"309.4200","5. volume":"27110529"}},{"Day":"2022-03-31","Time Series (Daily)":{"1. open":"313.9000","2. high":"315.1400","3. low":"307.8900","4. close":"308.3100","5. volume":"33422070"}},{"Day":"2022-03-30","Time Series (Daily)":{"1. open":"156.8800","2. high":"157.9750","3. low":"155.7900","4. close":"156.9300","5. volume":"28163555"}}],"Average Closing Price":258.22}`,
+		},
+		{
+			name: "resample cached prices into weekly bars",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?interval=weekly&from=2022-03-28&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceRange(gomock.Any(), symbol, time.Date(2022, 3, 28, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), 0).
+					Times(1).
+					Return(StockPrices, 313.21, nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: `{"Daily Price":[{"Day":"2022-04-01","Time Series (Daily)":{"1. open":"313.7600","2. high":"315.9500","3. low":"305.5400","4. close":"309.4200","5. volume":"88696154"}}],"Average Closing Price":309.42}`,
+		},
+		{
+			name: "weekly bars not cached so reads the weekly series of the api",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?interval=weekly&from=2022-03-28&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceRange(gomock.Any(), symbol, time.Date(2022, 3, 28, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), 0).
+					Times(1).
+					Return([]*api.DailyPrice{}, float64(0), nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					GetPeriodSeries(gomock.Any(), symbol, api.Weekly).
+					Times(1).
+					Return(&api.JSONResponse{DailyPrices: api.TimeSeriesDaily{
+						"2022-04-01": {Open: api.MustParseDecimal("304.3300"), High: api.MustParseDecimal("315.9500"), Low: api.MustParseDecimal("304.3300"), Close: api.MustParseDecimal("309.4200"), Volume: 118274320},
+						"2022-03-25": {Open: api.MustParseDecimal("298.5700"), High: api.MustParseDecimal("305.0000"), Low: api.MustParseDecimal("293.7000"), Close: api.MustParseDecimal("303.6800"), Volume: 120532680},
+					}}, nil)
+			},
+			expected: `{"Daily Price":[{"Day":"2022-04-01","Time Series (Daily)":{"1. open":"304.3300","2. high":"315.9500","3. low":"304.3300","4. close":"309.4200","5. volume":"118274320"}}],"Average Closing Price":309.42}`,
+		},
+		{
+			name: "weekly series of the api failing so resamples the daily prices of the provider",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?interval=weekly&from=2022-03-28&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				from, to := time.Date(2022, 3, 28, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+				gomock.InOrder(
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, to, 0).
+						Times(1).
+						Return([]*api.DailyPrice{}, float64(0), nil),
+					storageMock.EXPECT().
+						AddPrices(gomock.Any(), symbol, gomock.Any()).
+						Times(1).
+						Return(nil),
+					storageMock.EXPECT().
+						GetPriceRange(gomock.Any(), symbol, from, to, 0).
+						Times(1).
+						Return(StockPrices, 313.21, nil),
+				)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					GetPeriodSeries(gomock.Any(), symbol, api.Weekly).
+					Times(1).
+					Return(nil, &api.UpstreamError{Err: api.ErrRateLimited})
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Full).
+					Times(1).
+					Return(&api.Series{Symbol: symbol, Source: api.CSV, Prices: StockPrices}, nil)
+			},
+			expected: `{"Daily Price":[{"Day":"2022-04-01","Time Series (Daily)":{"1. open":"313.7600","2. high":"315.9500","3. low":"305.5400","4. close":"309.4200","5. volume":"88696154"}}],"Average Closing Price":309.42}`,
+		},
+		{
+			name:                "invalid interval",
+			w:                   httptest.NewRecorder(),
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?interval=5min", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			apiMockOutcomes:     func(apiMock *mock_api.MockAPI) {},
+			expected:            errorBody(`invalid interval "5min": expected one of daily, weekly, monthly or quarterly`),
+		},
+		{
+			name: "v2 response of cached prices",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v2/symbols/msft/prices", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), symbol, days).
+					Times(1).
+					Return(StockPrices, 313.21, nil)
+				storageMock.EXPECT().
+					GetLastRefreshed(gomock.Any(), symbol).
+					Times(1).
+					Return("2022-04-01", nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+			},
+			expected: `{"symbol":"MSFT","currency":"USD","as_of":"2022-04-01","source":"cache","interval":"daily","adjusted":false,"bars":[{"date":"2022-04-01","open":309.3700,"high":310.1300,"low":305.5400,"close":309.4200,"volume":27110529},{"date":"2022-03-31","open":313.9000,"high":315.1400,"low":307.8900,"close":308.3100,"volume":33422070},{"date":"2022-03-30","open":313.7600,"high":315.9500,"low":311.5800,"close":313.8600,"volume":28163555}],"summary":{"count":3,"average_close":313.21,"high":315.9500,"low":305.5400,"change":-4.4400,"change_percent":-1.41,"total_volume":88696154}}`,
+		},
+		{
+			name: "v2 response of prices read from the api",
+			w:    httptest.NewRecorder(),
+			r:    httptest.NewRequest(http.MethodGet, "/v2/symbols/MSFT/prices?days=3", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetPriceInfo(gomock.Any(), symbol, days).
+					Times(1).
+					Return([]*api.DailyPrice{}, float64(0), nil)
+			},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Daily(gomock.Any(), symbol, api.Compact).
+					Times(1).
+					Return(&api.Series{Symbol: symbol, Source: api.AlphaVantage, Prices: StockPrices[:1]}, nil)
+			},
+			expected: `{"symbol":"MSFT","currency":"USD","as_of":"2022-04-01","source":"alpha_vantage","interval":"daily","adjusted":false,"bars":[{"date":"2022-04-01","open":309.3700,"high":310.1300,"low":305.5400,"close":309.4200,"volume":27110529}],"summary":{"count":1,"average_close":309.42,"high":310.1300,"low":305.5400,"change":0.0000,"change_percent":0,"total_volume":27110529}}`,
+		},
+		{
+			name:                "v2 has prices only",
+			w:                   httptest.NewRecorder(),
+			r:                   httptest.NewRequest(http.MethodGet, "/v2/symbols/MSFT/bars", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			apiMockOutcomes:     func(apiMock *mock_api.MockAPI) {},
+			expected:            errorBody(_errNotFound),
+		},
+		{
+			name:                "invalid days",
+			w:                   httptest.NewRecorder(),
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/prices?days=0", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			apiMockOutcomes:     func(apiMock *mock_api.MockAPI) {},
+			expected:            errorBody(fmt.Sprintf("invalid days %q: expected a number between 1 and %d", "0", _maxDays)),
+		},
+		{
+			name:                "quota not managed by the client",
+			w:                   httptest.NewRecorder(),
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/quota", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					Quota().
+					Times(1).
+					Return(nil)
+			},
+			expected: errorBody(_errNoQuota),
+		},
+		{
+			name:                "unknown route",
+			w:                   httptest.NewRecorder(),
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/unknown", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			apiMockOutcomes:     func(apiMock *mock_api.MockAPI) {},
+			expected:            errorBody(_errNotFound),
 		},
 	}
 	for _, tt := range tests {
@@ -136,7 +443,9 @@ func Test_handler_ServeHTTP(t *testing.T) {
 			h := &handler{
 				apiClient: apiMock,
 				redis:     storageMock,
+				symbol:    symbol,
 				nDays:     days,
+				adjusted:  true,
 			}
 
 			h.ServeHTTP(tt.w, tt.r)
@@ -153,3 +462,317 @@ func Test_handler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+// waitForDone holds up a call to the api until the request it is made for ends
+func waitForDone(ctx context.Context, symbol string, size api.OutputSize) (*api.Series, error) {
+	<-ctx.Done()
+
+	return nil, fmt.Errorf("get %s: %w", symbol, ctx.Err())
+}
+
+func Test_handler_deadlines(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// ctx is the context of the request, cancelled when the client goes away
+		ctx      func() (context.Context, context.CancelFunc)
+		status   int
+		expected string
+	}{
+		{
+			name:    "a request outliving the timeout is cut short",
+			timeout: 10 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			status:   http.StatusGatewayTimeout,
+			expected: errorBody(_errTimeout),
+		},
+		{
+			name:    "the client going away cancels the upstream call",
+			timeout: time.Hour,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+
+				return ctx, cancel
+			},
+			status:   _statusClientClosedRequest,
+			expected: errorBody(_errCanceled),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			storageMock := mock_storage.NewMockStorage(mockController)
+			apiMock := mock_api.NewMockAPI(mockController)
+
+			storageMock.EXPECT().GetPriceInfo(gomock.Any(), "MSFT", 3).Times(1).Return([]*api.DailyPrice{}, float64(0), nil)
+			apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Compact).Times(1).DoAndReturn(waitForDone)
+
+			h := NewHandler(apiMock, nil, storageMock, "MSFT", 3, tt.timeout, false)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/symbols/MSFT/prices", nil).WithContext(ctx))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func Test_handler_notAdjusted(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	// nothing is read as no corporate actions are stored to adjust the prices with
+	h := NewHandler(mock_api.NewMockAPI(mockController), nil, mock_storage.NewMockStorage(mockController), "MSFT", 3,
+		DefaultTimeout, false)
+
+	for _, path := range []string{"/v1/symbols/MSFT/prices?adjusted=true", "/v2/symbols/MSFT/prices?adjusted=true"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Equal(t, errorBody(errNotAdjusted.Error()), w.Body.String(), path)
+	}
+}
+
+func Test_parsePriceQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		symbol   string
+		values   url.Values
+		expected priceQuery
+		err      string
+	}{
+		{
+			name:     "defaults to the configured days",
+			symbol:   "msft",
+			values:   url.Values{},
+			expected: priceQuery{symbol: "MSFT", days: 10, period: api.Daily},
+		},
+		{
+			name:     "days and range",
+			symbol:   "BRK.B",
+			values:   url.Values{"days": {"5"}, "from": {"2022-03-01"}, "to": {"2022-04-01"}},
+			expected: priceQuery{symbol: "BRK.B", days: 5, from: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), period: api.Daily},
+		},
+		{
+			name:     "range without days returns every day",
+			symbol:   "MSFT",
+			values:   url.Values{"to": {"2022-04-01"}},
+			expected: priceQuery{symbol: "MSFT", to: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), period: api.Daily},
+		},
+		{
+			name:     "adjusted prices",
+			symbol:   "MSFT",
+			values:   url.Values{"adjusted": {"true"}},
+			expected: priceQuery{symbol: "MSFT", days: 10, adjusted: true, period: api.Daily},
+		},
+		{
+			name:     "weekly bars",
+			symbol:   "MSFT",
+			values:   url.Values{"interval": {"weekly"}, "days": {"4"}},
+			expected: priceQuery{symbol: "MSFT", days: 4, period: api.Weekly},
+		},
+		{
+			name:   "invalid interval",
+			symbol: "MSFT",
+			values: url.Values{"interval": {"yearly"}},
+			err:    `invalid interval "yearly": expected one of daily, weekly, monthly or quarterly`,
+		},
+		{
+			name:   "invalid adjusted",
+			symbol: "MSFT",
+			values: url.Values{"adjusted": {"raw"}},
+			err:    `invalid adjusted "raw": expected true or false`,
+		},
+		{
+			name:   "invalid symbol",
+			symbol: "MS FT",
+			values: url.Values{},
+			err:    `invalid symbol "MS FT"`,
+		},
+		{
+			name:   "days over 20 years",
+			symbol: "MSFT",
+			values: url.Values{"days": {"10000"}},
+			err:    fmt.Sprintf(`invalid days "10000": expected a number between 1 and %d`, _maxDays),
+		},
+		{
+			name:   "invalid date",
+			symbol: "MSFT",
+			values: url.Values{"from": {"01/04/2022"}},
+			err:    `invalid from "01/04/2022": expected YYYY-MM-DD`,
+		},
+		{
+			name:   "from after to",
+			symbol: "MSFT",
+			values: url.Values{"from": {"2022-04-02"}, "to": {"2022-04-01"}},
+			err:    "from must not be after to",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parsePriceQuery(tt.symbol, tt.values, 10)
+			if err != nil {
+				assert.Equal(t, tt.err, err.Error())
+
+				return
+			}
+
+			assert.Empty(t, tt.err)
+			assert.Equal(t, tt.expected, q)
+		})
+	}
+}
+
+func Test_writeUpstreamError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		expected string
+	}{
+		{
+			name:     "rate limited",
+			err:      &api.UpstreamError{Err: api.ErrRateLimited, Message: "5 calls per minute"},
+			status:   http.StatusTooManyRequests,
+			expected: errorBody(api.ErrRateLimited.Error()),
+		},
+		{
+			name:     "daily quota exhausted",
+			err:      fmt.Errorf("%s: %w", _errCache, &api.UpstreamError{Err: api.ErrQuotaExceeded, Message: "500 calls per day"}),
+			status:   http.StatusTooManyRequests,
+			expected: errorBody(api.ErrQuotaExceeded.Error()),
+		},
+		{
+			name:     "invalid symbol",
+			err:      &api.UpstreamError{Err: api.ErrInvalidSymbol, Message: "Invalid API call"},
+			status:   http.StatusNotFound,
+			expected: errorBody(api.ErrInvalidSymbol.Error()),
+		},
+		{
+			name:     "invalid api key",
+			err:      &api.UpstreamError{Err: api.ErrInvalidAPIKey, Message: "the parameter apikey is invalid"},
+			status:   http.StatusBadGateway,
+			expected: errorBody(api.ErrUpstream.Error()),
+		},
+		{
+			name:     "upstream timed out",
+			err:      fmt.Errorf("%s: %w", _errCache, &api.UpstreamError{Err: api.ErrTimeout}),
+			status:   http.StatusGatewayTimeout,
+			expected: errorBody(api.ErrTimeout.Error()),
+		},
+		{
+			name:     "request timed out",
+			err:      fmt.Errorf("get cached prices: %w", context.DeadlineExceeded),
+			status:   http.StatusGatewayTimeout,
+			expected: errorBody(_errTimeout),
+		},
+		{
+			name:     "client went away",
+			err:      fmt.Errorf("get cached prices: %w", context.Canceled),
+			status:   _statusClientClosedRequest,
+			expected: errorBody(_errCanceled),
+		},
+		{
+			name:     "any other error",
+			err:      errors.New("test error"),
+			status:   http.StatusInternalServerError,
+			expected: errorBody(_errResponse),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			writeUpstreamError(w, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func errorBody(msg string) string {
+	resp, _ := json.Marshal(errorResponse{Error: msg})
+
+	return string(resp)
+}
+
+func Test_handler_providers(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	csvMock := mock_api.NewMockProvider(mockController)
+
+	defer mockController.Finish()
+
+	prices := []*api.DailyPrice{
+		{Day: "2022-04-01", Price: &api.Price{Open: api.MustParseDecimal("350.81"), High: api.MustParseDecimal("354.65"), Low: api.MustParseDecimal("349.29"), Close: api.MustParseDecimal("352.91"), Volume: 3710040}},
+	}
+
+	storageMock.EXPECT().GetPriceInfo(gomock.Any(), "BRK.B", 1).Times(1).Return([]*api.DailyPrice{}, float64(0), nil)
+	csvMock.EXPECT().
+		Daily(gomock.Any(), "BRK.B", api.Compact).
+		Times(1).
+		Return(&api.Series{Symbol: "BRK.B", Source: api.CSV, LastRefreshed: "2022-04-01", Prices: prices}, nil)
+
+	h := &handler{
+		apiClient: apiMock,
+		providers: api.NewProviders(apiMock, map[string]api.Provider{"BRK.B": csvMock}),
+		redis:     storageMock,
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/symbols/brk.b/prices?days=1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"symbol":"BRK.B","currency":"USD","as_of":"2022-04-01","source":"csv","interval":"daily","adjusted":false,"bars":[{"date":"2022-04-01","open":350.81,"high":354.65,"low":349.29,"close":352.91,"volume":3710040}],"summary":{"count":1,"average_close":352.91,"high":354.65,"low":349.29,"change":0.00,"change_percent":0,"total_volume":3710040}}`, w.Body.String())
+}
+
+func Test_handler_fakeUpstream(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	defer mockController.Finish()
+
+	upstream := fake.New(fake.WithEnd(time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)), fake.WithHistory(200))
+
+	srv := httptest.NewServer(upstream)
+	defer srv.Close()
+
+	h := &handler{
+		apiClient: api.New(api.WithBaseURL(srv.URL), api.WithKey("demo"), api.WithMaxRetries(0), api.WithTimeout(time.Second)),
+		redis:     storageMock,
+	}
+
+	storageMock.EXPECT().GetPriceInfo(gomock.Any(), "IBM", 3).Times(2).Return([]*api.DailyPrice{}, float64(0), nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/symbols/IBM/prices?days=3", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"as_of":"2022-04-01","source":"alpha_vantage"`)
+
+	// throttled by the api
+	upstream.Fail(fake.Note)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/symbols/IBM/prices?days=3", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, errorBody(api.ErrRateLimited.Error()), w.Body.String())
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"stock_ticker/analytics"
+	"stock_ticker/api"
+)
+
+const _errTooFewPrices = "at least 2 prices are needed for statistics"
+
+// _periodsPerYear are the number of bars of each interval in a year, statistics are annualized with them
+var _periodsPerYear = map[api.Period]int{
+	api.Daily:     analytics.TradingDays,
+	api.Weekly:    52,
+	api.Monthly:   12,
+	api.Quarterly: 4,
+}
+
+// statisticsQuery holds the validated values of a request for statistics
+type statisticsQuery struct {
+	priceQuery
+	riskFree float64
+}
+
+// statisticsResponse is the response of the statistics of a window of prices. Returns, volatility and ratios are
+// fractions, ratios are null when they are undefined.
+type statisticsResponse struct {
+	Symbol     string        `json:"symbol"`
+	Interval   string        `json:"interval"`
+	Adjusted   bool          `json:"adjusted"`
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Count      int           `json:"count"`
+	Returns    returnsStats  `json:"returns"`
+	Volatility float64       `json:"annualized_volatility"`
+	Sharpe     *float64      `json:"sharpe"`
+	Sortino    *float64      `json:"sortino"`
+	RiskFree   float64       `json:"risk_free_rate"`
+	Drawdown   drawdownStats `json:"max_drawdown"`
+	Week52     week52Stats   `json:"week_52"`
+	Closes     closesStats   `json:"closes"`
+}
+
+type returnsStats struct {
+	Simple     float64 `json:"simple"`
+	Log        float64 `json:"log"`
+	MeanSimple float64 `json:"mean_simple"`
+	MeanLog    float64 `json:"mean_log"`
+}
+
+type drawdownStats struct {
+	Depth  float64 `json:"depth"`
+	Peak   string  `json:"peak,omitempty"`
+	Trough string  `json:"trough,omitempty"`
+}
+
+type week52Stats struct {
+	High     float64 `json:"high"`
+	HighDate string  `json:"high_date"`
+	Low      float64 `json:"low"`
+	LowDate  string  `json:"low_date"`
+}
+
+type closesStats struct {
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// parseStatisticsQuery validates the price query parameters along with the annual risk_free rate
+func parseStatisticsQuery(symbol string, values url.Values, defaultDays int) (statisticsQuery, error) {
+	prices, err := parsePriceQuery(symbol, values, defaultDays)
+	if err != nil {
+		return statisticsQuery{}, err
+	}
+
+	q := statisticsQuery{priceQuery: prices}
+
+	if riskFree := values.Get("risk_free"); riskFree != "" {
+		if q.riskFree, err = strconv.ParseFloat(riskFree, 64); err != nil || q.riskFree < -1 || q.riskFree > 1 {
+			return statisticsQuery{}, fmt.Errorf("invalid risk_free %q: expected a fraction between -1 and 1", riskFree)
+		}
+	}
+
+	return q, nil
+}
+
+// GetStatistics is a handler responsible for the return and risk statistics of the prices matching the query
+func (h *handler) GetStatistics(ctx context.Context, w http.ResponseWriter, q statisticsQuery) {
+	resp, err := h.statistics(ctx, q)
+	if errors.Is(err, analytics.ErrTooFewBars) {
+		writeError(w, http.StatusUnprocessableEntity, _errTooFewPrices)
+
+		return
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("symbol", q.symbol).Msg("compute statistics")
+
+		writeUpstreamError(w, err)
+
+		return
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("compute statistics")
+
+		writeError(w, http.StatusInternalServerError, _errResponse)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// statistics computes the statistics of the prices of the query. The year before the last price is read too for its
+// 52 week high and low.
+func (h *handler) statistics(ctx context.Context, q statisticsQuery) (*statisticsResponse, error) {
+	prices, _, err := h.prices(ctx, yearBack(q.priceQuery, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	bars := analytics.Bars(prices.DailyPrices)
+	window := bars
+
+	switch {
+	case !q.isRange() && len(window) > q.days:
+		window = window[len(window)-q.days:]
+	case !q.from.IsZero():
+		first := 0
+		for first < len(window) && window[first].Date < q.from.Format(api.Format) {
+			first++
+		}
+
+		window = window[first:]
+	}
+
+	stats, err := analytics.Summarize(window, _periodsPerYear[q.period], q.riskFree)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := time.Parse(api.Format, stats.To)
+	if err != nil {
+		return nil, err
+	}
+
+	high, low := analytics.Range(bars, to.AddDate(0, 0, -52*7).Format(api.Format))
+
+	resp := &statisticsResponse{
+		Symbol:   q.symbol,
+		Interval: string(q.period),
+		Adjusted: q.adjusted,
+		From:     stats.From,
+		To:       stats.To,
+		Count:    stats.Count,
+		Returns: returnsStats{
+			Simple:     api.FixedPrecision(stats.SimpleReturn, 6),
+			Log:        api.FixedPrecision(stats.LogReturn, 6),
+			MeanSimple: api.FixedPrecision(stats.MeanReturn, 6),
+			MeanLog:    api.FixedPrecision(stats.MeanLogReturn, 6),
+		},
+		Volatility: api.FixedPrecision(stats.Volatility, 6),
+		Sharpe:     ratio(stats.Sharpe),
+		Sortino:    ratio(stats.Sortino),
+		RiskFree:   q.riskFree,
+		Drawdown: drawdownStats{
+			Depth:  api.FixedPrecision(stats.MaxDrawdown.Depth, 6),
+			Peak:   stats.MaxDrawdown.Peak,
+			Trough: stats.MaxDrawdown.Trough,
+		},
+		Week52: week52Stats{
+			High:     api.FixedPrecision(high.Value, 4),
+			HighDate: high.Date,
+			Low:      api.FixedPrecision(low.Value, 4),
+			LowDate:  low.Date,
+		},
+		Closes: closesStats{
+			Median:      api.FixedPrecision(stats.Closes[50], 4),
+			Percentiles: make(map[string]float64, len(stats.Closes)),
+		},
+	}
+
+	for p, value := range stats.Closes {
+		resp.Closes.Percentiles[fmt.Sprintf("p%g", p)] = api.FixedPrecision(value, 4)
+	}
+
+	return resp, nil
+}
+
+// yearBack returns the query extended to cover at least the 52 weeks before its last day
+func yearBack(q priceQuery, now time.Time) priceQuery {
+	switch {
+	case !q.isRange():
+		// a tenth more bars than a year for holidays
+		if year := _periodsPerYear[q.period] * 11 / 10; q.days < year {
+			q.days = year
+		}
+	case !q.from.IsZero():
+		to := q.to
+		if to.IsZero() {
+			to = now
+		}
+
+		if year := to.AddDate(0, 0, -52*7); year.Before(q.from) {
+			q.from = year
+		}
+	}
+
+	return q
+}
+
+// ratio rounds the ratio, nil when it is undefined
+func ratio(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+
+	value = api.FixedPrecision(value, 6)
+
+	return &value
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/api/mocks"
+	"stock_ticker/storage/mocks"
+)
+
+func Test_handler_GetStatistics(t *testing.T) {
+	mockController := gomock.NewController(t)
+
+	storageMock := mock_storage.NewMockStorage(mockController)
+
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	prices := []*api.DailyPrice{
+		{Day: "2022-04-01", Price: &api.Price{Open: api.MustParseDecimal("309.3700"), High: api.MustParseDecimal("310.1300"), Low: api.MustParseDecimal("305.5400"), Close: api.MustParseDecimal("309.4200"), Volume: 27110529}},
+		{Day: "2022-03-31", Price: &api.Price{Open: api.MustParseDecimal("313.9000"), High: api.MustParseDecimal("315.1400"), Low: api.MustParseDecimal("307.8900"), Close: api.MustParseDecimal("308.3100"), Volume: 33422070}},
+		{Day: "2022-03-30", Price: &api.Price{Open: api.MustParseDecimal("313.7600"), High: api.MustParseDecimal("315.9500"), Low: api.MustParseDecimal("311.5800"), Close: api.MustParseDecimal("313.8600"), Volume: 28163555}},
+		// more than 52 weeks before the last day so left out of the 52 week high and low
+		{Day: "2021-03-31", Price: &api.Price{Open: api.MustParseDecimal("235.9000"), High: api.MustParseDecimal("239.1000"), Low: api.MustParseDecimal("232.3900"), Close: api.MustParseDecimal("235.7700"), Volume: 43623471}},
+	}
+
+	tests := []struct {
+		name                string
+		r                   *http.Request
+		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
+		status              int
+		expected            string
+	}{
+		{
+			name: "statistics of the last days",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/msft/statistics?days=3", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().GetPriceInfo(gomock.Any(), "MSFT", 277).Times(1).Return(prices, 266.84, nil)
+				// the cache holds fewer days than the year before them asked for so the full history is read
+				apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).
+					Return(&api.Series{Symbol: "MSFT", Source: api.AlphaVantage, Prices: prices}, nil)
+			},
+			status:   http.StatusOK,
+			expected: `{"symbol":"MSFT","interval":"daily","adjusted":false,"from":"2022-03-30","to":"2022-04-01","count":3,"returns":{"simple":-0.014146,"log":-0.014247,"mean_simple":-0.007041,"mean_log":-0.007124},"annualized_volatility":0.240608,"sharpe":-7.427354,"sortino":-8.939565,"risk_free_rate":0,"max_drawdown":{"depth":-0.017683,"peak":"2022-03-30","trough":"2022-03-31"},"week_52":{"high":315.95,"high_date":"2022-03-30","low":305.54,"low_date":"2022-04-01"},"closes":{"median":309.42,"percentiles":{"p25":308.865,"p5":308.421,"p50":309.42,"p75":311.64,"p95":313.416}}}`,
+		},
+		{
+			name: "a range is extended by a year",
+			r:    httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/statistics?from=2022-04-01&to=2022-04-01", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				from, to := time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC), time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+				// the cache starts long after the year before the range so the full history is cached first, which
+				// holds no more days
+				gomock.InOrder(
+					storageMock.EXPECT().GetPriceRange(gomock.Any(), "MSFT", from, to, 0).Times(1).Return(prices[:3], 310.53, nil),
+					apiMock.EXPECT().Daily(gomock.Any(), "MSFT", api.Full).Times(1).Return(&api.Series{Symbol: "MSFT", Source: api.AlphaVantage}, nil),
+					storageMock.EXPECT().AddPrices(gomock.Any(), "MSFT", gomock.Any()).Times(1).Return(nil),
+					storageMock.EXPECT().GetPriceRange(gomock.Any(), "MSFT", from, to, 0).Times(1).Return(prices[:3], 310.53, nil),
+				)
+			},
+			status:   http.StatusUnprocessableEntity,
+			expected: errorBody(_errTooFewPrices),
+		},
+		{
+			name:                "invalid risk free rate",
+			r:                   httptest.NewRequest(http.MethodGet, "/v1/symbols/MSFT/statistics?risk_free=2%25", nil),
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			status:              http.StatusBadRequest,
+			expected:            errorBody(`invalid risk_free "2%": expected a fraction between -1 and 1`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.storageMockOutcomes(storageMock)
+
+			h := &handler{
+				apiClient: apiMock,
+				redis:     storageMock,
+				nDays:     10,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func Test_parseStatisticsQuery(t *testing.T) {
+	q, err := parseStatisticsQuery("msft", url.Values{"risk_free": {"0.02"}, "interval": {"monthly"}, "days": {"12"}}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, statisticsQuery{priceQuery: priceQuery{symbol: "MSFT", days: 12, period: api.Monthly}, riskFree: 0.02}, q)
+}
@@ -0,0 +1,481 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"stock_ticker/api"
+)
+
+const (
+	// _boltOpenTimeout is how long opening the file waits for another process to release it
+	_boltOpenTimeout = time.Second
+
+	_boltPricesBucket     = "prices"
+	_boltActionsBucket    = "actions"
+	_boltBarsBucket       = "bars"
+	_boltRefreshedKey     = "refreshed"
+	_boltBarsRefreshedKey = "bars_refreshed"
+)
+
+// _boltSymbolsBucket holds a bucket per symbol, each with:
+//   - prices: the daily prices keyed by day, days sorting as they follow one another so ranges are read with a cursor
+//   - actions: the corporate actions keyed by day
+//   - bars: a bucket per interval of the intraday bars keyed by the big endian unix time they start at
+//   - refreshed: the last refreshed meta data of the latest prices added
+//   - bars_refreshed: a bucket of the unix time bars were last added at, keyed by interval
+var _boltSymbolsBucket = []byte("symbols")
+
+// BoltOption specifies a builder function for configuring a Bolt storage
+type BoltOption func(*Bolt)
+
+// Bolt is an implementation of Storage interface on a single file, for deployments without a redis. The file keeps the
+// prices across restarts but can only be opened by one process at a time so it is not shared between replicas.
+type Bolt struct {
+	db *bolt.DB
+
+	intradayRetention time.Duration
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Storage = (*Bolt)(nil)
+
+// NewBolt opens the storage in the file at path, creating it when it does not exist
+func NewBolt(path string, opts ...BoltOption) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: _boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(_boltSymbolsBucket)
+
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("create symbols bucket: %w", err)
+	}
+
+	b := &Bolt{
+		db:                db,
+		intradayRetention: DefaultIntradayRetention,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b, nil
+}
+
+// WithBoltIntradayRetention sets how long intraday bars are kept for, measured back from the latest bar
+func WithBoltIntradayRetention(d time.Duration) BoltOption {
+	return func(b *Bolt) {
+		if d > 0 {
+			b.intradayRetention = d
+		}
+	}
+}
+
+// Close releases the file
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// view runs fn in a read only transaction unless ctx has ended, transactions being too short lived to abandon midway
+func (b *Bolt) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return b.db.View(fn)
+}
+
+// update runs fn in a read write transaction unless ctx has ended
+func (b *Bolt) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return b.db.Update(fn)
+}
+
+// symbolBucket returns the bucket of symbol, nil when nothing is stored for it in a read only transaction
+func symbolBucket(tx *bolt.Tx, symbol string) (*bolt.Bucket, error) {
+	symbols := tx.Bucket(_boltSymbolsBucket)
+	name := []byte(normalizeSymbol(symbol))
+
+	if !tx.Writable() {
+		return symbols.Bucket(name), nil
+	}
+
+	return symbols.CreateBucketIfNotExists(name)
+}
+
+// nestedBucket returns the bucket called name within parent, nil when it does not exist in a read only transaction
+func nestedBucket(tx *bolt.Tx, parent *bolt.Bucket, name string) (*bolt.Bucket, error) {
+	if parent == nil {
+		return nil, nil
+	}
+
+	if !tx.Writable() {
+		return parent.Bucket([]byte(name)), nil
+	}
+
+	return parent.CreateBucketIfNotExists([]byte(name))
+}
+
+func (b *Bolt) AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error {
+	if len(prices.DailyPrices) == 0 {
+		return nil
+	}
+
+	// every day is checked before any is written as the prices are added in one transaction
+	for date := range prices.DailyPrices {
+		if _, err := dayScore(date); err != nil {
+			return err
+		}
+	}
+
+	return b.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return fmt.Errorf("add stock prices: %w", err)
+		}
+
+		pricesBucket, err := nestedBucket(tx, bucket, _boltPricesBucket)
+		if err != nil {
+			return fmt.Errorf("add stock prices: %w", err)
+		}
+
+		for date, price := range prices.DailyPrices {
+			value, err := json.Marshal(price)
+			if err != nil {
+				return fmt.Errorf("marshal price: %w", err)
+			}
+
+			if err = pricesBucket.Put([]byte(date), value); err != nil {
+				return fmt.Errorf("add stock prices: %w", err)
+			}
+		}
+
+		if prices.MetaData.LastRefreshed != "" {
+			if err = bucket.Put([]byte(_boltRefreshedKey), []byte(prices.MetaData.LastRefreshed)); err != nil {
+				return fmt.Errorf("set last refreshed: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetLastRefreshed returns the "Last Refreshed" meta data of the latest prices added for the symbol, empty when
+// no prices have been added
+func (b *Bolt) GetLastRefreshed(ctx context.Context, symbol string) (string, error) {
+	var refreshed string
+
+	err := b.view(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if bucket == nil {
+			return err
+		}
+
+		refreshed = string(bucket.Get([]byte(_boltRefreshedKey)))
+
+		return nil
+	})
+
+	return refreshed, err
+}
+
+// GetPriceInfo returns the latest days worth of prices for the symbol along with their average closing price
+func (b *Bolt) GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error) {
+	return b.GetPriceRange(ctx, symbol, time.Time{}, time.Time{}, days)
+}
+
+// GetPriceRange returns the prices for the symbol between from and to inclusive, latest first, along with
+// their average closing price. A zero from or to leaves that end of the range open and days <= 0 returns every
+// day in the range. Days are read walking back from to so only the days returned are read.
+func (b *Bolt) GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error) {
+	nDaysData := make([]*api.DailyPrice, 0)
+
+	var totClose api.Decimal
+
+	err := b.view(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return err
+		}
+
+		pricesBucket, err := nestedBucket(tx, bucket, _boltPricesBucket)
+		if pricesBucket == nil {
+			return err
+		}
+
+		cursor := pricesBucket.Cursor()
+
+		var key, value []byte
+
+		if to.IsZero() {
+			key, value = cursor.Last()
+		} else {
+			// seek lands on the first day on or after to, nowhere when to is after the last day
+			last := to.Format(api.Format)
+
+			switch key, value = cursor.Seek([]byte(last)); {
+			case key == nil:
+				key, value = cursor.Last()
+			case string(key) > last:
+				key, value = cursor.Prev()
+			}
+		}
+
+		for ; key != nil; key, value = cursor.Prev() {
+			if days > 0 && len(nDaysData) == days {
+				break
+			}
+
+			if !from.IsZero() && string(key) < from.Format(api.Format) {
+				break
+			}
+
+			price := api.Price{}
+			if err = json.Unmarshal(value, &price); err != nil {
+				return fmt.Errorf("unmarshal price: %w", err)
+			}
+
+			totClose = totClose.Add(price.Close)
+
+			nDaysData = append(nDaysData, &api.DailyPrice{
+				Day:   string(key),
+				Price: &price,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("read prices: %w", err)
+	}
+
+	if len(nDaysData) == 0 {
+		return nDaysData, 0, nil
+	}
+
+	// average close price rounded to 2 decimal places
+	avgClose := totClose.Div(int64(len(nDaysData)), 2).Float64()
+
+	return nDaysData, avgClose, nil
+}
+
+// AddCorporateActions merges the actions into those stored for the symbol
+func (b *Bolt) AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	return b.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return fmt.Errorf("add corporate actions: %w", err)
+		}
+
+		actionsBucket, err := nestedBucket(tx, bucket, _boltActionsBucket)
+		if err != nil {
+			return fmt.Errorf("add corporate actions: %w", err)
+		}
+
+		for day, action := range actions {
+			value, err := json.Marshal(action)
+			if err != nil {
+				return fmt.Errorf("marshal corporate action: %w", err)
+			}
+
+			if err = actionsBucket.Put([]byte(day), value); err != nil {
+				return fmt.Errorf("add corporate actions: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetCorporateActions returns the actions of the symbol taking effect on or after from, all of them when from is zero
+func (b *Bolt) GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error) {
+	actions := make(api.CorporateActions)
+
+	err := b.view(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return err
+		}
+
+		actionsBucket, err := nestedBucket(tx, bucket, _boltActionsBucket)
+		if actionsBucket == nil {
+			return err
+		}
+
+		cursor := actionsBucket.Cursor()
+
+		key, value := cursor.First()
+		if !from.IsZero() {
+			key, value = cursor.Seek([]byte(from.Format(api.Format)))
+		}
+
+		for ; key != nil; key, value = cursor.Next() {
+			var action api.CorporateAction
+			if err = json.Unmarshal(value, &action); err != nil {
+				return fmt.Errorf("unmarshal corporate actions: %w", err)
+			}
+
+			actions[string(key)] = action
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read corporate actions: %w", err)
+	}
+
+	return actions, nil
+}
+
+// barKey is the key of a bar, its unix time big endian so bars sort by time
+func barKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.Unix()))
+
+	return key
+}
+
+// AddBars stores the bars and drops those older than the retention window
+func (b *Bolt) AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	return b.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return fmt.Errorf("add bar: %w", err)
+		}
+
+		barsBucket, err := nestedBucket(tx, bucket, _boltBarsBucket)
+		if err != nil {
+			return fmt.Errorf("add bar: %w", err)
+		}
+
+		intervalBucket, err := barsBucket.CreateBucketIfNotExists([]byte(interval))
+		if err != nil {
+			return fmt.Errorf("add bar: %w", err)
+		}
+
+		var latest time.Time
+
+		for _, bar := range bars {
+			value, err := json.Marshal(bar)
+			if err != nil {
+				return fmt.Errorf("marshal bar: %w", err)
+			}
+
+			if err = intervalBucket.Put(barKey(bar.Timestamp), value); err != nil {
+				return fmt.Errorf("add bar: %w", err)
+			}
+
+			if bar.Timestamp.After(latest) {
+				latest = bar.Timestamp
+			}
+		}
+
+		// keys are collected first as deleting moves the cursor
+		cutoff := string(barKey(latest.Add(-b.intradayRetention)))
+
+		var expired [][]byte
+
+		cursor := intervalBucket.Cursor()
+		for key, _ := cursor.First(); key != nil && string(key) < cutoff; key, _ = cursor.Next() {
+			expired = append(expired, key)
+		}
+
+		for _, key := range expired {
+			if err = intervalBucket.Delete(key); err != nil {
+				return fmt.Errorf("delete expired bars: %w", err)
+			}
+		}
+
+		refreshedBucket, err := nestedBucket(tx, bucket, _boltBarsRefreshedKey)
+		if err != nil {
+			return fmt.Errorf("set bars refreshed: %w", err)
+		}
+
+		if err = refreshedBucket.Put([]byte(interval), []byte(strconv.FormatInt(time.Now().Unix(), 10))); err != nil {
+			return fmt.Errorf("set bars refreshed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetBars returns the latest n bars of the symbol at the interval, latest first, along with when bars were last
+// added. The time is zero when no bars have been added and n <= 0 returns every bar.
+func (b *Bolt) GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error) {
+	bars := make([]*api.IntradayBar, 0)
+
+	var refreshed time.Time
+
+	err := b.view(ctx, func(tx *bolt.Tx) error {
+		bucket, err := symbolBucket(tx, symbol)
+		if err != nil {
+			return err
+		}
+
+		refreshedBucket, err := nestedBucket(tx, bucket, _boltBarsRefreshedKey)
+		if refreshedBucket == nil || refreshedBucket.Get([]byte(interval)) == nil {
+			return err
+		}
+
+		unix, err := strconv.ParseInt(string(refreshedBucket.Get([]byte(interval))), 10, 64)
+		if err != nil {
+			return fmt.Errorf("read bars refreshed: %w", err)
+		}
+
+		refreshed = time.Unix(unix, 0)
+
+		barsBucket, err := nestedBucket(tx, bucket, _boltBarsBucket)
+		if barsBucket == nil || barsBucket.Bucket([]byte(interval)) == nil {
+			return err
+		}
+
+		cursor := barsBucket.Bucket([]byte(interval)).Cursor()
+		for key, value := cursor.Last(); key != nil && (n <= 0 || len(bars) < n); key, value = cursor.Prev() {
+			var bar api.IntradayBar
+			if err = json.Unmarshal(value, &bar); err != nil {
+				return fmt.Errorf("unmarshal bar: %w", err)
+			}
+
+			bars = append(bars, &bar)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read bars: %w", err)
+	}
+
+	return bars, refreshed, nil
+}
+
+// Ping checks the file is still open
+func (b *Bolt) Ping(ctx context.Context) error {
+	return b.view(ctx, func(tx *bolt.Tx) error {
+		return nil
+	})
+}
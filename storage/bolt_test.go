@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/storage"
+	"stock_ticker/storage/storagetest"
+)
+
+func newBolt(t *testing.T, path string) *storage.Bolt {
+	b, err := storage.NewBolt(path, storage.WithBoltIntradayRetention(storagetest.IntradayRetention))
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+
+	return b
+}
+
+func TestBolt(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		b := newBolt(t, filepath.Join(t.TempDir(), "stock-ticker.db"))
+		t.Cleanup(func() { _ = b.Close() })
+
+		return b
+	})
+}
+
+func TestBolt_reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "stock-ticker.db")
+
+	price := api.Price{
+		Open:   api.MustParseDecimal("129.6600"),
+		High:   api.MustParseDecimal("130.2700"),
+		Low:    api.MustParseDecimal("128.0600"),
+		Close:  api.MustParseDecimal("130.1500"),
+		Volume: 4012373,
+	}
+
+	b := newBolt(t, path)
+	assert.NoError(t, b.AddPrices(ctx, "IBM", &api.JSONResponse{
+		MetaData:    api.MD{LastRefreshed: "2022-04-01"},
+		DailyPrices: api.TimeSeriesDaily{"2022-04-01": price},
+	}))
+	assert.NoError(t, b.Close())
+
+	assert.Error(t, b.Ping(ctx))
+
+	// the file is locked while open
+	b = newBolt(t, path)
+	defer b.Close()
+
+	_, err := storage.NewBolt(path)
+	assert.Error(t, err)
+
+	prices, avgClose, err := b.GetPriceInfo(ctx, "IBM", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*api.DailyPrice{{Day: "2022-04-01", Price: &price}}, prices)
+	assert.Equal(t, 130.15, avgClose)
+
+	refreshed, err := b.GetLastRefreshed(ctx, "IBM")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01", refreshed)
+
+	_, refreshedBars, err := b.GetBars(ctx, "IBM", api.OneMinute, 10)
+	assert.NoError(t, err)
+	assert.True(t, refreshedBars.IsZero())
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nitishm/go-rejson/v4"
+
+	"stock_ticker/api"
+)
+
+const (
+	_barsPrefix = "bars"
+
+	// DefaultIntradayRetention covers the trailing 30 days the api returns intraday bars for
+	DefaultIntradayRetention = 30 * 24 * time.Hour
+)
+
+// BarKey returns the key a bar is stored under, by the unix time it starts at e.g. bars:MSFT:5min:1648857300
+func BarKey(symbol string, interval api.Interval, member string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", _barsPrefix, normalizeSymbol(symbol), interval, member)
+}
+
+// BarIndexKey returns the key of the sorted set indexing the bars of a symbol at an interval by their unix time
+// e.g. bars:MSFT:5min:index
+func BarIndexKey(symbol string, interval api.Interval) string {
+	return fmt.Sprintf("%s:%s:%s:index", _barsPrefix, normalizeSymbol(symbol), interval)
+}
+
+// BarRefreshedKey returns the key of the unix time bars were last added for a symbol at an interval
+// e.g. bars:MSFT:5min:refreshed
+func BarRefreshedKey(symbol string, interval api.Interval) string {
+	return fmt.Sprintf("%s:%s:%s:refreshed", _barsPrefix, normalizeSymbol(symbol), interval)
+}
+
+// AddBars stores the bars and drops those older than the retention window in a single transaction, its commands
+// pipelined in batches as prices are
+func (r *Redis) AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	commands := make([]command, 0, len(bars)+4)
+	index := redis.Args{}.Add(BarIndexKey(symbol, interval))
+
+	var latest time.Time
+
+	for _, bar := range bars {
+		member := strconv.FormatInt(bar.Timestamp.Unix(), 10)
+
+		value, err := json.Marshal(bar)
+		if err != nil {
+			return fmt.Errorf("add bars: %w", err)
+		}
+
+		commands = append(commands, command{name: "JSON.SET", args: redis.Args{BarKey(symbol, interval, member), ".", value}})
+		index = index.Add(bar.Timestamp.Unix(), member)
+
+		if bar.Timestamp.After(latest) {
+			latest = bar.Timestamp
+		}
+	}
+
+	commands = append(commands, command{name: "ZADD", args: index})
+
+	cutoff := latest.Add(-r.retention())
+
+	err := r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		trim, err := trimBars(conn, symbol, interval, cutoff, bars)
+		if err != nil {
+			return err
+		}
+
+		// built afresh as the operation is retried on a new connection when its connection breaks
+		queued := make([]command, 0, len(commands)+len(trim)+1)
+		queued = append(queued, commands...)
+		queued = append(queued, trim...)
+		queued = append(queued, command{name: "SET", args: redis.Args{BarRefreshedKey(symbol, interval), time.Now().Unix()}})
+
+		return r.transaction(conn, queued)
+	})
+	if err != nil {
+		return fmt.Errorf("add bars: %w", err)
+	}
+
+	return nil
+}
+
+// trimBars returns the commands removing the bars starting before cutoff, those stored already and those being added
+func trimBars(conn redis.Conn, symbol string, interval api.Interval, cutoff time.Time, bars []*api.IntradayBar) ([]command, error) {
+	max := fmt.Sprintf("(%d", cutoff.Unix())
+
+	expired, err := redis.Strings(conn.Do("ZRANGEBYSCORE", BarIndexKey(symbol, interval), "-inf", max))
+	if err != nil {
+		return nil, fmt.Errorf("read expired bars: %w", err)
+	}
+
+	for _, bar := range bars {
+		if bar.Timestamp.Before(cutoff) {
+			expired = append(expired, strconv.FormatInt(bar.Timestamp.Unix(), 10))
+		}
+	}
+
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	keys := redis.Args{}
+	for _, member := range expired {
+		keys = keys.Add(BarKey(symbol, interval, member))
+	}
+
+	return []command{
+		{name: "DEL", args: keys},
+		{name: "ZREMRANGEBYSCORE", args: redis.Args{BarIndexKey(symbol, interval), "-inf", max}},
+	}, nil
+}
+
+// GetBars returns the latest n bars of the symbol at the interval, latest first, along with when bars were last
+// added. The time is zero when no bars have been added and n <= 0 returns every bar.
+func (r *Redis) GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error) {
+	var (
+		refreshed time.Time
+		values    [][]byte
+	)
+
+	err := r.do(ctx, func(conn redis.Conn, rh *rejson.Handler) error {
+		refreshed, values = time.Time{}, nil
+
+		unix, err := redis.Int64(conn.Do("GET", BarRefreshedKey(symbol, interval)))
+		switch {
+		case err == redis.ErrNil:
+			return nil
+		case err != nil:
+			return fmt.Errorf("read bars refreshed: %w", err)
+		}
+
+		refreshed = time.Unix(unix, 0)
+
+		// a stop of -1 is the last member of the index
+		stop := n - 1
+		if n <= 0 {
+			stop = -1
+		}
+
+		members, err := redis.Strings(conn.Do("ZREVRANGE", BarIndexKey(symbol, interval), 0, stop))
+		if err != nil {
+			return fmt.Errorf("read bar index: %w", err)
+		}
+
+		if len(members) == 0 {
+			return nil
+		}
+
+		keys := make([]string, len(members))
+		for i, member := range members {
+			keys[i] = BarKey(symbol, interval, member)
+		}
+
+		values, err = redis.ByteSlices(rh.JSONMGet(".", keys...))
+		if err != nil {
+			return fmt.Errorf("read bars: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, refreshed, err
+	}
+
+	bars := make([]*api.IntradayBar, 0, len(values))
+
+	for _, value := range values {
+		if value == nil { // expired between reading the index and the bars
+			continue
+		}
+
+		var bar api.IntradayBar
+		if err = json.Unmarshal(value, &bar); err != nil {
+			return nil, refreshed, fmt.Errorf("unmarshal bar: %w", err)
+		}
+
+		bars = append(bars, &bar)
+	}
+
+	return bars, refreshed, nil
+}
+
+func (r *Redis) retention() time.Duration {
+	if r.IntradayRetention <= 0 {
+		return DefaultIntradayRetention
+	}
+
+	return r.IntradayRetention
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"stock_ticker/api"
+)
+
+// MemoryOption specifies a builder function for configuring a Memory storage
+type MemoryOption func(*Memory)
+
+// Memory is an implementation of Storage interface keeping everything in the memory of the process. It behaves as
+// Redis does so the service can run, and be tested, without a redis, but nothing outlives the process nor is shared
+// between replicas.
+type Memory struct {
+	intradayRetention time.Duration
+
+	mu      sync.RWMutex
+	symbols map[string]*memorySymbol
+}
+
+// memorySymbol is what is stored for a symbol
+type memorySymbol struct {
+	prices    map[string]api.Price
+	refreshed string
+	actions   api.CorporateActions
+	bars      map[api.Interval]*memoryBars
+}
+
+// memoryBars are the bars of a symbol at an interval keyed by the unix time they start at
+type memoryBars struct {
+	bars      map[int64]*api.IntradayBar
+	refreshed time.Time
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Storage = (*Memory)(nil)
+
+// NewMemory initializes an empty in memory storage
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{
+		intradayRetention: DefaultIntradayRetention,
+		symbols:           make(map[string]*memorySymbol),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// WithMemoryIntradayRetention sets how long intraday bars are kept for, measured back from the latest bar
+func WithMemoryIntradayRetention(d time.Duration) MemoryOption {
+	return func(m *Memory) {
+		if d > 0 {
+			m.intradayRetention = d
+		}
+	}
+}
+
+// symbol returns what is stored for symbol, adding it when nothing is. The caller holds the write lock.
+func (m *Memory) symbol(symbol string) *memorySymbol {
+	symbol = normalizeSymbol(symbol)
+
+	s, ok := m.symbols[symbol]
+	if !ok {
+		s = &memorySymbol{
+			prices:  make(map[string]api.Price),
+			actions: make(api.CorporateActions),
+			bars:    make(map[api.Interval]*memoryBars),
+		}
+		m.symbols[symbol] = s
+	}
+
+	return s
+}
+
+func (m *Memory) AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(prices.DailyPrices) == 0 {
+		return nil
+	}
+
+	for date := range prices.DailyPrices {
+		if _, err := dayScore(date); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.symbol(symbol)
+
+	for date, price := range prices.DailyPrices {
+		s.prices[date] = price
+	}
+
+	if prices.MetaData.LastRefreshed != "" {
+		s.refreshed = prices.MetaData.LastRefreshed
+	}
+
+	return nil
+}
+
+// GetLastRefreshed returns the "Last Refreshed" meta data of the latest prices added for the symbol, empty when
+// no prices have been added
+func (m *Memory) GetLastRefreshed(ctx context.Context, symbol string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.symbols[normalizeSymbol(symbol)]
+	if !ok {
+		return "", nil
+	}
+
+	return s.refreshed, nil
+}
+
+// GetPriceInfo returns the latest days worth of prices for the symbol along with their average closing price
+func (m *Memory) GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error) {
+	return m.GetPriceRange(ctx, symbol, time.Time{}, time.Time{}, days)
+}
+
+// GetPriceRange returns the prices for the symbol between from and to inclusive, latest first, along with
+// their average closing price. A zero from or to leaves that end of the range open and days <= 0 returns every
+// day in the range.
+func (m *Memory) GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nDaysData := make([]*api.DailyPrice, 0)
+
+	s, ok := m.symbols[normalizeSymbol(symbol)]
+	if !ok {
+		return nDaysData, 0, nil
+	}
+
+	for date, price := range s.prices {
+		// days are validated when added
+		score, _ := dayScore(date)
+		if (!from.IsZero() && score < timeScore(from)) || (!to.IsZero() && score > timeScore(to)) {
+			continue
+		}
+
+		price := price
+		nDaysData = append(nDaysData, &api.DailyPrice{
+			Day:   date,
+			Price: &price,
+		})
+	}
+
+	sort.Slice(nDaysData, func(i, j int) bool {
+		return nDaysData[i].Day > nDaysData[j].Day
+	})
+
+	if days > 0 && len(nDaysData) > days {
+		nDaysData = nDaysData[:days]
+	}
+
+	if len(nDaysData) == 0 {
+		return nDaysData, 0, nil
+	}
+
+	var totClose api.Decimal
+	for _, price := range nDaysData {
+		totClose = totClose.Add(price.Price.Close)
+	}
+
+	// average close price rounded to 2 decimal places
+	avgClose := totClose.Div(int64(len(nDaysData)), 2).Float64()
+
+	return nDaysData, avgClose, nil
+}
+
+// AddCorporateActions merges the actions into those stored for the symbol
+func (m *Memory) AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.symbol(symbol)
+
+	for day, action := range actions {
+		s.actions[day] = action
+	}
+
+	return nil
+}
+
+// GetCorporateActions returns the actions of the symbol taking effect on or after from, all of them when from is zero
+func (m *Memory) GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	actions := make(api.CorporateActions)
+
+	s, ok := m.symbols[normalizeSymbol(symbol)]
+	if !ok {
+		return actions, nil
+	}
+
+	for day, action := range s.actions {
+		if from.IsZero() || day >= from.Format(api.Format) {
+			actions[day] = action
+		}
+	}
+
+	return actions, nil
+}
+
+// AddBars stores the bars and drops those older than the retention window
+func (m *Memory) AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(bars) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.symbol(symbol)
+
+	stored, ok := s.bars[interval]
+	if !ok {
+		stored = &memoryBars{bars: make(map[int64]*api.IntradayBar)}
+		s.bars[interval] = stored
+	}
+
+	var latest time.Time
+
+	for _, bar := range bars {
+		stored.bars[bar.Timestamp.Unix()] = copyBar(bar)
+
+		if bar.Timestamp.After(latest) {
+			latest = bar.Timestamp
+		}
+	}
+
+	cutoff := latest.Add(-m.intradayRetention).Unix()
+	for unix := range stored.bars {
+		if unix < cutoff {
+			delete(stored.bars, unix)
+		}
+	}
+
+	// kept to the second as redis keeps it
+	stored.refreshed = time.Unix(time.Now().Unix(), 0)
+
+	return nil
+}
+
+// GetBars returns the latest n bars of the symbol at the interval, latest first, along with when bars were last
+// added. The time is zero when no bars have been added and n <= 0 returns every bar.
+func (m *Memory) GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bars := make([]*api.IntradayBar, 0)
+
+	s, ok := m.symbols[normalizeSymbol(symbol)]
+	if !ok || s.bars[interval] == nil {
+		return bars, time.Time{}, nil
+	}
+
+	stored := s.bars[interval]
+
+	unixes := make([]int64, 0, len(stored.bars))
+	for unix := range stored.bars {
+		unixes = append(unixes, unix)
+	}
+
+	sort.Slice(unixes, func(i, j int) bool {
+		return unixes[i] > unixes[j]
+	})
+
+	if n > 0 && len(unixes) > n {
+		unixes = unixes[:n]
+	}
+
+	for _, unix := range unixes {
+		bars = append(bars, copyBar(stored.bars[unix]))
+	}
+
+	return bars, stored.refreshed, nil
+}
+
+// Ping succeeds unless ctx has ended as there is nothing to reach
+func (m *Memory) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// copyBar copies the bar so what is stored cannot be changed through what was added or returned
+func copyBar(bar *api.IntradayBar) *api.IntradayBar {
+	copied := *bar
+
+	if bar.Price != nil {
+		price := *bar.Price
+		copied.Price = &price
+	}
+
+	return &copied
+}
@@ -0,0 +1,14 @@
+package storage_test
+
+import (
+	"testing"
+
+	"stock_ticker/storage"
+	"stock_ticker/storage/storagetest"
+)
+
+func TestMemory(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemory(storage.WithMemoryIntradayRetention(storagetest.IntradayRetention))
+	})
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// _migrationLock is the key of the advisory lock replicas starting together take so only one migrates
+const _migrationLock = 7215319
+
+//go:embed migrations/*.sql
+var _migrations embed.FS
+
+// migration is a change to the schema, applied in the order of the versions
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrations returns the migrations embedded in the binary, named <version>_<name>.sql e.g. 0001_create_bars.sql
+func migrations() ([]migration, error) {
+	files, err := _migrations.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	all := make([]migration, 0, len(files))
+
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".sql")
+
+		i := strings.IndexByte(name, '_')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid migration %q: expected <version>_<name>.sql", file.Name())
+		}
+
+		version, err := strconv.Atoi(name[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration %q: expected <version>_<name>.sql", file.Name())
+		}
+
+		body, err := _migrations.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", file.Name(), err)
+		}
+
+		all = append(all, migration{version: version, name: name[i+1:], sql: string(body)})
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].version < all[j].version
+	})
+
+	for i := 1; i < len(all); i++ {
+		if all[i].version == all[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", all[i].version)
+		}
+	}
+
+	return all, nil
+}
+
+// Migrate applies the migrations the database has not had yet and returns how many it applied. They are applied in
+// one transaction so a failing migration leaves the schema as it was.
+func (p *Postgres) Migrate(ctx context.Context) (int, error) {
+	all, err := migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", _migrationLock); err != nil {
+		return 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    integer     PRIMARY KEY,
+		name       text        NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+
+	for _, m := range all {
+		if applied[m.version] {
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, m.sql); err != nil {
+			return n, fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+
+		if _, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.version, m.name); err != nil {
+			return n, fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+
+		log.Info().Int("version", m.version).Str("name", m.name).Msg("applied migration")
+
+		n++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	return n, nil
+}
+
+func appliedVersions(ctx context.Context, tx *sql.Tx) (map[int]bool, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+
+	for rows.Next() {
+		var version int
+		if err = rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("read applied migrations: %w", err)
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
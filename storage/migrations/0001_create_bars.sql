@@ -0,0 +1,31 @@
+-- bars are the prices of every symbol, the daily prices at the daily interval and the intraday bars at theirs. The
+-- primary key is the index range reads walk.
+CREATE TABLE bars (
+    symbol      text        NOT NULL,
+    "interval"  text        NOT NULL,
+    "timestamp" timestamptz NOT NULL,
+    open        numeric,
+    high        numeric,
+    low         numeric,
+    close       numeric,
+    volume      bigint,
+    PRIMARY KEY (symbol, "interval", "timestamp")
+);
+
+-- series keeps when the bars of a symbol at an interval were last added and the last refreshed meta data of the api
+CREATE TABLE series (
+    symbol         text        NOT NULL,
+    "interval"     text        NOT NULL,
+    last_refreshed text,
+    updated_at     timestamptz NOT NULL,
+    PRIMARY KEY (symbol, "interval")
+);
+
+CREATE TABLE corporate_actions (
+    symbol            text             NOT NULL,
+    day               date             NOT NULL,
+    dividend_amount   numeric          NOT NULL,
+    split_coefficient numeric          NOT NULL,
+    factor            double precision NOT NULL,
+    PRIMARY KEY (symbol, day)
+);
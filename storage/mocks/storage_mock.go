@@ -5,8 +5,10 @@
 package mock_storage
 
 import (
+	context "context"
 	reflect "reflect"
 	api "stock_ticker/api"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,24 +36,98 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddBars mocks base method.
+func (m *MockStorage) AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBars", ctx, symbol, interval, bars)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddBars indicates an expected call of AddBars.
+func (mr *MockStorageMockRecorder) AddBars(ctx, symbol, interval, bars interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBars", reflect.TypeOf((*MockStorage)(nil).AddBars), ctx, symbol, interval, bars)
+}
+
+// AddCorporateActions mocks base method.
+func (m *MockStorage) AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCorporateActions", ctx, symbol, actions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCorporateActions indicates an expected call of AddCorporateActions.
+func (mr *MockStorageMockRecorder) AddCorporateActions(ctx, symbol, actions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCorporateActions", reflect.TypeOf((*MockStorage)(nil).AddCorporateActions), ctx, symbol, actions)
+}
+
 // AddPrices mocks base method.
-func (m *MockStorage) AddPrices(prices *api.JSONResponse) error {
+func (m *MockStorage) AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddPrices", prices)
+	ret := m.ctrl.Call(m, "AddPrices", ctx, symbol, prices)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddPrices indicates an expected call of AddPrices.
-func (mr *MockStorageMockRecorder) AddPrices(prices interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) AddPrices(ctx, symbol, prices interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPrices", reflect.TypeOf((*MockStorage)(nil).AddPrices), ctx, symbol, prices)
+}
+
+// GetBars mocks base method.
+func (m *MockStorage) GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBars", ctx, symbol, interval, n)
+	ret0, _ := ret[0].([]*api.IntradayBar)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBars indicates an expected call of GetBars.
+func (mr *MockStorageMockRecorder) GetBars(ctx, symbol, interval, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBars", reflect.TypeOf((*MockStorage)(nil).GetBars), ctx, symbol, interval, n)
+}
+
+// GetCorporateActions mocks base method.
+func (m *MockStorage) GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCorporateActions", ctx, symbol, from)
+	ret0, _ := ret[0].(api.CorporateActions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCorporateActions indicates an expected call of GetCorporateActions.
+func (mr *MockStorageMockRecorder) GetCorporateActions(ctx, symbol, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCorporateActions", reflect.TypeOf((*MockStorage)(nil).GetCorporateActions), ctx, symbol, from)
+}
+
+// GetLastRefreshed mocks base method.
+func (m *MockStorage) GetLastRefreshed(ctx context.Context, symbol string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastRefreshed", ctx, symbol)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastRefreshed indicates an expected call of GetLastRefreshed.
+func (mr *MockStorageMockRecorder) GetLastRefreshed(ctx, symbol interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPrices", reflect.TypeOf((*MockStorage)(nil).AddPrices), prices)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastRefreshed", reflect.TypeOf((*MockStorage)(nil).GetLastRefreshed), ctx, symbol)
 }
 
 // GetPriceInfo mocks base method.
-func (m *MockStorage) GetPriceInfo(days int) ([]*api.DailyPrice, float64, error) {
+func (m *MockStorage) GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPriceInfo", days)
+	ret := m.ctrl.Call(m, "GetPriceInfo", ctx, symbol, days)
 	ret0, _ := ret[0].([]*api.DailyPrice)
 	ret1, _ := ret[1].(float64)
 	ret2, _ := ret[2].(error)
@@ -59,7 +135,75 @@ func (m *MockStorage) GetPriceInfo(days int) ([]*api.DailyPrice, float64, error)
 }
 
 // GetPriceInfo indicates an expected call of GetPriceInfo.
-func (mr *MockStorageMockRecorder) GetPriceInfo(days interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetPriceInfo(ctx, symbol, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPriceInfo", reflect.TypeOf((*MockStorage)(nil).GetPriceInfo), ctx, symbol, days)
+}
+
+// GetPriceRange mocks base method.
+func (m *MockStorage) GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPriceRange", ctx, symbol, from, to, days)
+	ret0, _ := ret[0].([]*api.DailyPrice)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPriceRange indicates an expected call of GetPriceRange.
+func (mr *MockStorageMockRecorder) GetPriceRange(ctx, symbol, from, to, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPriceRange", reflect.TypeOf((*MockStorage)(nil).GetPriceRange), ctx, symbol, from, to, days)
+}
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
+}
+
+// MockMigrator is a mock of Migrator interface.
+type MockMigrator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigratorMockRecorder
+}
+
+// MockMigratorMockRecorder is the mock recorder for MockMigrator.
+type MockMigratorMockRecorder struct {
+	mock *MockMigrator
+}
+
+// NewMockMigrator creates a new mock instance.
+func NewMockMigrator(ctrl *gomock.Controller) *MockMigrator {
+	mock := &MockMigrator{ctrl: ctrl}
+	mock.recorder = &MockMigratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMigrator) EXPECT() *MockMigratorMockRecorder {
+	return m.recorder
+}
+
+// MigrateBareKeys mocks base method.
+func (m *MockMigrator) MigrateBareKeys(ctx context.Context, symbol string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateBareKeys", ctx, symbol)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateBareKeys indicates an expected call of MigrateBareKeys.
+func (mr *MockMigratorMockRecorder) MigrateBareKeys(ctx, symbol interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPriceInfo", reflect.TypeOf((*MockStorage)(nil).GetPriceInfo), days)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateBareKeys", reflect.TypeOf((*MockMigrator)(nil).MigrateBareKeys), ctx, symbol)
 }
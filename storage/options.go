@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"time"
+)
+
+// Option specifies a builder function for configuring a Redis storage
+type Option func(*Redis)
+
+// WithIntradayRetention sets how long intraday bars are kept for, measured back from the latest bar
+func WithIntradayRetention(d time.Duration) Option {
+	return func(r *Redis) {
+		r.IntradayRetention = d
+	}
+}
+
+// WithBatchSize sets the number of commands pipelined to redis at a time when adding prices
+func WithBatchSize(n int) Option {
+	return func(r *Redis) {
+		r.BatchSize = n
+	}
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultMaxIdle is the number of idle connections kept open
+	DefaultMaxIdle = 10
+	// DefaultMaxActive is the number of connections open at once, borrowers wait for one to be returned beyond it
+	DefaultMaxActive = 50
+	// DefaultIdleTimeout is how long a connection is kept idle before it is closed
+	DefaultIdleTimeout = 5 * time.Minute
+	// DefaultConnectTimeout is how long dialling redis may take
+	DefaultConnectTimeout = 5 * time.Second
+	// DefaultReadTimeout is how long reading a reply may take
+	DefaultReadTimeout = 5 * time.Second
+	// DefaultWriteTimeout is how long writing a command may take
+	DefaultWriteTimeout = 5 * time.Second
+	// DefaultHealthCheckPeriod is how long a connection may be idle before it is pinged when borrowed
+	DefaultHealthCheckPeriod = time.Minute
+
+	// _reconnects is the number of times an operation is retried on a new connection when its connection breaks
+	_reconnects = 1
+)
+
+// PoolOption specifies a builder function for configuring a Pool
+type PoolOption func(*Pool)
+
+// Pool hands out connections to redis. Every operation borrows its own connection so concurrent requests do not share
+// one, and connections broken by redis restarting are replaced rather than reused.
+type Pool struct {
+	// broken is the unix nano time a connection was last found broken, idle connections returned before it are
+	// pinged when borrowed whatever the health check period. It comes first to be 64-bit aligned for atomic access.
+	broken int64
+
+	pool *redis.Pool
+
+	maxIdle, maxActive int
+
+	idleTimeout       time.Duration
+	connectTimeout    time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	healthCheckPeriod time.Duration
+}
+
+// NewPool initializes a pool of connections to the redis at address. Connections are dialled when first borrowed.
+func NewPool(address, password string, opts ...PoolOption) *Pool {
+	p := &Pool{
+		maxIdle:           DefaultMaxIdle,
+		maxActive:         DefaultMaxActive,
+		idleTimeout:       DefaultIdleTimeout,
+		connectTimeout:    DefaultConnectTimeout,
+		readTimeout:       DefaultReadTimeout,
+		writeTimeout:      DefaultWriteTimeout,
+		healthCheckPeriod: DefaultHealthCheckPeriod,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.pool = &redis.Pool{
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(ctx, "tcp", address,
+				redis.DialPassword(password),
+				redis.DialConnectTimeout(p.connectTimeout),
+				redis.DialReadTimeout(p.readTimeout),
+				redis.DialWriteTimeout(p.writeTimeout))
+		},
+		TestOnBorrow: p.testOnBorrow,
+		MaxIdle:      p.maxIdle,
+		MaxActive:    p.maxActive,
+		IdleTimeout:  p.idleTimeout,
+		Wait:         true,
+	}
+
+	return p
+}
+
+// WithPoolSize sets the number of idle connections kept open and the number of connections open at once, zero
+// active connections being unlimited
+func WithPoolSize(maxIdle, maxActive int) PoolOption {
+	return func(p *Pool) {
+		p.maxIdle = maxIdle
+		p.maxActive = maxActive
+	}
+}
+
+// WithIdleTimeout sets how long a connection is kept idle before it is closed, zero keeping it open
+func WithIdleTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.idleTimeout = d
+	}
+}
+
+// WithConnectTimeout sets how long dialling redis may take
+func WithConnectTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.connectTimeout = d
+	}
+}
+
+// WithReadTimeout sets how long reading a reply may take
+func WithReadTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets how long writing a command may take
+func WithWriteTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.writeTimeout = d
+	}
+}
+
+// WithHealthCheckPeriod sets how long a connection may be idle before it is pinged when borrowed, zero pinging it
+// every time
+func WithHealthCheckPeriod(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.healthCheckPeriod = d
+	}
+}
+
+// Stats returns the number of active and idle connections
+func (p *Pool) Stats() redis.PoolStats {
+	return p.pool.Stats()
+}
+
+// Close closes the idle connections, those borrowed are closed when returned
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
+
+// do runs fn with a connection borrowed for it, the commands fn runs on the connection being bound by ctx. When the
+// connection breaks, redis having restarted or the network having dropped it, fn is run again on another connection.
+// Redis error replies leave the connection usable and are returned as they are, and ctx ending returns its error.
+func (p *Pool) do(ctx context.Context, fn func(conn redis.Conn) error) error {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// an error getting the connection is returned by the connection as well, and so reconnected on
+		conn, _ := p.pool.GetContext(ctx)
+
+		err := fn(contextConn{Conn: conn, ctx: ctx})
+		broken := conn.Err()
+
+		_ = conn.Close()
+
+		// the connection ctx ended on is closed, which is no reason to reconnect
+		if ctx.Err() != nil {
+			return contextError(ctx, err)
+		}
+
+		if broken == nil || attempt == _reconnects {
+			return err
+		}
+
+		atomic.StoreInt64(&p.broken, time.Now().UnixNano())
+
+		log.Warn().Err(broken).Msg("redis connection broken, reconnecting")
+	}
+}
+
+// testOnBorrow pings connections idle for longer than the health check period or since before a connection was found
+// broken, the pool closing those that fail and dialling a new one
+func (p *Pool) testOnBorrow(conn redis.Conn, lastUsed time.Time) error {
+	if time.Since(lastUsed) < p.healthCheckPeriod && lastUsed.UnixNano() > atomic.LoadInt64(&p.broken) {
+		return nil
+	}
+
+	_, err := conn.Do("PING")
+
+	return err
+}
+
+// contextConn runs the commands of a connection within the context of the operation that borrowed it, so the
+// deadline of the context bounds every reply and cancelling it abandons the command in flight
+type contextConn struct {
+	redis.Conn
+	ctx context.Context
+}
+
+func (c contextConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	return redis.DoContext(c.Conn, c.ctx, commandName, args...)
+}
+
+func (c contextConn) Receive() (interface{}, error) {
+	return redis.ReceiveContext(c.Conn, c.ctx)
+}
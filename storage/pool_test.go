@@ -0,0 +1,380 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis answers PING, GET, ZRANGEBYSCORE and SCAN over the redis protocol, queues writes in MULTI/EXEC
+// transactions and can be killed and restarted on the same address
+type fakeRedis struct {
+	t    *testing.T
+	addr string
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]bool
+	// executed are the names of the commands transactions ran, in order
+	executed []string
+	// keys are those a SCAN returns, in a single page
+	keys []string
+	// stalled is set to read commands without ever replying, as a redis that stopped answering does
+	stalled int32
+}
+
+func startFakeRedis(t *testing.T) *fakeRedis {
+	f := &fakeRedis{t: t, addr: "127.0.0.1:0"}
+	f.restart()
+
+	t.Cleanup(f.kill)
+
+	return f
+}
+
+// restart listens again on the address the fake was first started on
+func (f *fakeRedis) restart() {
+	ln, err := net.Listen("tcp", f.addr)
+	if err != nil {
+		f.t.Fatalf("listen: %v", err)
+	}
+
+	f.mu.Lock()
+	f.ln, f.addr, f.conns = ln, ln.Addr().String(), make(map[net.Conn]bool)
+	f.mu.Unlock()
+
+	go f.serve(ln)
+}
+
+// kill stops listening and drops every connection as redis going down does
+func (f *fakeRedis) kill() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_ = f.ln.Close()
+
+	for conn := range f.conns {
+		_ = conn.Close()
+	}
+}
+
+func (f *fakeRedis) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.conns[conn] = true
+		f.mu.Unlock()
+
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	var (
+		multi  bool
+		queued []string
+	)
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		reply := "-ERR unknown command\r\n"
+		name := strings.ToUpper(args[0])
+
+		switch {
+		case name == "MULTI":
+			multi, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			f.mu.Lock()
+			f.executed = append(f.executed, queued...)
+			f.mu.Unlock()
+
+			reply = "*" + strconv.Itoa(len(queued)) + "\r\n"
+			for _, queuedName := range queued {
+				reply += execReply(queuedName)
+			}
+
+			multi, queued = false, nil
+		case name == "DISCARD":
+			multi, queued = false, nil
+			reply = "+OK\r\n"
+		case multi:
+			queued = append(queued, name)
+			reply = "+QUEUED\r\n"
+		case name == "PING":
+			reply = "+PONG\r\n"
+		case name == "GET":
+			reply = "$-1\r\n"
+		case name == "ZRANGEBYSCORE":
+			reply = "*0\r\n"
+		case name == "SCAN":
+			f.mu.Lock()
+			reply = "*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(f.keys)) + "\r\n"
+			for _, key := range f.keys {
+				reply += "$" + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n"
+			}
+			f.mu.Unlock()
+		}
+
+		if atomic.LoadInt32(&f.stalled) == 1 {
+			continue
+		}
+
+		if _, err = io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// execReply is the reply a transaction gives for a queued command, those counting keys as having changed one
+func execReply(name string) string {
+	switch name {
+	case "RENAMENX", "ZADD":
+		return ":1\r\n"
+	default:
+		return "+OK\r\n"
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	n, err := readLength(reader, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+
+	for i := range args {
+		size, err := readLength(reader, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		arg := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(arg[:size])
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	return args, nil
+}
+
+func readLength(reader *bufio.Reader, prefix byte) (int, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
+
+// fillPool leaves n connections idle in the pool
+func fillPool(t *testing.T, r *Redis, n int) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, r.Ping(context.Background()))
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestPool_restart(t *testing.T) {
+	tests := []struct {
+		name string
+		// down is called while redis is down
+		down func(t *testing.T, r *Redis)
+	}{
+		{
+			name: "reconnects after failing while redis is down",
+			down: func(t *testing.T, r *Redis) {
+				assert.Error(t, r.Ping(context.Background()))
+			},
+		},
+		{
+			name: "reconnects with no request while redis was down",
+			down: func(t *testing.T, r *Redis) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeRedis(t)
+
+			// health checks are left to the reconnects
+			pool := NewPool(fake.addr, "", WithPoolSize(4, 4), WithHealthCheckPeriod(time.Hour),
+				WithConnectTimeout(time.Second), WithReadTimeout(time.Second), WithWriteTimeout(time.Second))
+			defer pool.Close()
+
+			r := &Redis{Pool: pool}
+
+			fillPool(t, r, 4)
+
+			fake.kill()
+			tt.down(t, r)
+			fake.restart()
+
+			// the first request after the restart is served whatever connections were left idle
+			assert.NoError(t, r.Ping(context.Background()))
+
+			refreshed, err := r.GetLastRefreshed(context.Background(), "IBM")
+			assert.NoError(t, err)
+			assert.Empty(t, refreshed)
+
+			fillPool(t, r, 4)
+		})
+	}
+}
+
+func TestPool_restartMidTraffic(t *testing.T) {
+	fake := startFakeRedis(t)
+
+	pool := NewPool(fake.addr, "", WithPoolSize(4, 8), WithHealthCheckPeriod(time.Hour),
+		WithConnectTimeout(time.Second), WithReadTimeout(time.Second), WithWriteTimeout(time.Second))
+	defer pool.Close()
+
+	r := &Redis{Pool: pool}
+
+	var (
+		restarted int32
+		stop      = make(chan struct{})
+		wg        sync.WaitGroup
+		served    int64
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				after := atomic.LoadInt32(&restarted) == 1
+
+				_, err := r.GetLastRefreshed(context.Background(), "IBM")
+				switch {
+				case err == nil:
+					atomic.AddInt64(&served, 1)
+				case after:
+					t.Errorf("request after the restart failed: %v", err)
+				}
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	fake.kill()
+	time.Sleep(50 * time.Millisecond)
+	fake.restart()
+	atomic.StoreInt32(&restarted, 1)
+
+	before := atomic.LoadInt64(&served)
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	assert.Greater(t, atomic.LoadInt64(&served), before, "no request served after the restart")
+	assert.LessOrEqual(t, pool.Stats().ActiveCount, 8)
+}
+
+func TestPool_deadline(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		{
+			name: "a reply that does not come within the deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+		{
+			name: "the caller going away",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeRedis(t)
+
+			// the read timeout is left longer than the test so only the context ends the operation
+			pool := NewPool(fake.addr, "", WithPoolSize(1, 1), WithReadTimeout(time.Minute))
+			defer pool.Close()
+
+			r := &Redis{Pool: pool}
+
+			assert.NoError(t, r.Ping(context.Background()))
+
+			atomic.StoreInt32(&fake.stalled, 1)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+
+			_, err := r.GetLastRefreshed(ctx, "IBM")
+			assert.True(t, errors.Is(err, tt.want), err)
+			assert.Less(t, int64(time.Since(start)), int64(time.Second), "the operation outlived its context")
+
+			// the connection abandoned is not reused
+			atomic.StoreInt32(&fake.stalled, 0)
+
+			assert.NoError(t, r.Ping(context.Background()))
+		})
+	}
+}
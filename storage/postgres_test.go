@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpsertBarsQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		values string
+	}{
+		{
+			name:   "a single bar",
+			n:      1,
+			values: "($1, $2, $3, $4, $5, $6, $7, $8)",
+		},
+		{
+			name:   "bars are numbered on from the previous",
+			n:      2,
+			values: "($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := upsertBarsQuery(tt.n)
+
+			assert.True(t, strings.HasPrefix(query, `INSERT INTO bars (symbol, "interval", "timestamp", open, high, low, close, volume) VALUES `+tt.values+" ON CONFLICT"), query)
+			assert.Contains(t, query, `ON CONFLICT (symbol, "interval", "timestamp") DO UPDATE SET`)
+		})
+	}
+}
+
+func TestWithPostgresBatchSize(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want int
+	}{
+		{name: "sets the batch size", n: 200, want: 200},
+		{name: "ignores a size that is not positive", n: 0, want: DefaultBatchSize},
+		{name: "ignores a size beyond the parameters of a statement", n: 10000, want: DefaultBatchSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Postgres{batchSize: DefaultBatchSize}
+			WithPostgresBatchSize(tt.n)(p)
+
+			assert.Equal(t, tt.want, p.batchSize)
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	all, err := migrations()
+	assert.NoError(t, err)
+
+	if !assert.NotEmpty(t, all) {
+		return
+	}
+
+	assert.Equal(t, 1, all[0].version)
+	assert.Equal(t, "create_bars", all[0].name)
+	assert.Contains(t, all[0].sql, "CREATE TABLE bars")
+
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1].version, all[i].version)
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"stock_ticker/api"
+)
+
+const (
+	_quotaPrefix = "quota"
+
+	// _quotaTTL keeps a days counter a little longer than the day it counts
+	_quotaTTL = 48 * time.Hour
+)
+
+// QuotaCounter keeps the number of api requests made each day in redis so every replica shares the daily quota
+type QuotaCounter struct {
+	pool *Pool
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ api.QuotaCounter = (*QuotaCounter)(nil)
+
+// NewQuotaCounter initializes a counter on the redis connections of pool
+func NewQuotaCounter(pool *Pool) *QuotaCounter {
+	return &QuotaCounter{pool: pool}
+}
+
+// QuotaKey returns the key the number of requests made on a day is kept under e.g. quota:2022-04-01
+func QuotaKey(day string) string {
+	return fmt.Sprintf("%s:%s", _quotaPrefix, day)
+}
+
+// Incr counts a request made on day. A request counted before its connection broke is counted again, erring on the
+// side of the quota.
+func (q *QuotaCounter) Incr(ctx context.Context, day string) (int, error) {
+	var used int
+
+	err := q.pool.do(ctx, func(conn redis.Conn) error {
+		var err error
+
+		used, err = redis.Int(conn.Do("INCR", QuotaKey(day)))
+		if err != nil {
+			return err
+		}
+
+		if used == 1 {
+			_, err = conn.Do("EXPIRE", QuotaKey(day), int(_quotaTTL.Seconds()))
+		}
+
+		return err
+	})
+
+	return used, err
+}
+
+func (q *QuotaCounter) Get(ctx context.Context, day string) (int, error) {
+	var used int
+
+	err := q.pool.do(ctx, func(conn redis.Conn) error {
+		var err error
+
+		used, err = redis.Int(conn.Do("GET", QuotaKey(day)))
+		if err == redis.ErrNil {
+			used, err = 0, nil
+		}
+
+		return err
+	})
+
+	return used, err
+}
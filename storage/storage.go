@@ -1,112 +1,520 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/nitishm/go-rejson/v4"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/nitishm/go-rejson/v4"
 	"github.com/rs/zerolog/log"
 
 	"stock_ticker/api"
 )
 
+const (
+	// DefaultBatchSize is the number of prices written at a time, pipelined to redis or upserted by a postgres statement
+	DefaultBatchSize = 1000
+
+	_pricesPrefix = "prices"
+
+	// _bareKeyPattern matches the date-only keys written before prices were scoped per symbol
+	_bareKeyPattern = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]"
+)
+
 // Storage is the interface for storage operations
 type Storage interface {
-	AddPrices(prices *api.JSONResponse) error
-	GetPriceInfo(days int) ([]*api.DailyPrice, float64, error)
+	AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error
+	GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error)
+	GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error)
+	GetLastRefreshed(ctx context.Context, symbol string) (string, error)
+	AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error
+	GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error)
+	AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error
+	GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error)
+	Ping(ctx context.Context) error
+}
+
+// Migrator is implemented by storages that can move data written by older versions of the service
+type Migrator interface {
+	MigrateBareKeys(ctx context.Context, symbol string) (int, error)
 }
 
 // Redis is the implementation of Storage interface
 type Redis struct {
-	Rh *rejson.Handler
+	// Pool lends every operation its own connection
+	Pool *Pool
+
+	// IntradayRetention is how long intraday bars are kept for, DefaultIntradayRetention when zero
+	IntradayRetention time.Duration
+
+	// BatchSize is the number of commands pipelined to redis at a time, DefaultBatchSize when zero
+	BatchSize int
 }
 
-func New(address, password string) (Storage, error) {
-	reJsonHandler := rejson.NewReJSONHandler()
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var (
+	_ Storage  = (*Redis)(nil)
+	_ Migrator = (*Redis)(nil)
+)
+
+// New initializes a storage on the redis connections of pool and checks redis can be reached
+func New(pool *Pool, opts ...Option) (Storage, error) {
+	r := &Redis{
+		Pool: pool,
+	}
 
-	// Redigo Client
-	conn, err := redis.Dial("tcp", address, redis.DialPassword(password))
-	if err != nil {
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if err := r.Ping(context.Background()); err != nil {
 		return nil, err
 	}
 
-	reJsonHandler.SetRedigoClient(conn)
+	return r, nil
+}
 
-	return &Redis{
-		Rh: reJsonHandler,
-	}, nil
+// do runs fn with a connection borrowed from the pool and a RedisJSON handler on it
+func (r *Redis) do(ctx context.Context, fn func(conn redis.Conn, rh *rejson.Handler) error) error {
+	return r.Pool.do(ctx, func(conn redis.Conn) error {
+		rh := rejson.NewReJSONHandler()
+		rh.SetRedigoClient(conn)
+
+		return fn(conn, rh)
+	})
 }
 
-func (r *Redis) AddPrices(prices *api.JSONResponse) error {
-	for date, price := range prices.DailyPrices {
-		res, err := r.Rh.JSONSet(date, ".", price)
+// PriceKey returns the key a days price is stored under for a symbol e.g. prices:MSFT:2022-04-01
+func PriceKey(symbol, day string) string {
+	return fmt.Sprintf("%s:%s:%s", _pricesPrefix, normalizeSymbol(symbol), day)
+}
+
+// IndexKey returns the key of the sorted set indexing the days stored for a symbol e.g. prices:MSFT:index.
+// Members are the days and scores the day as a YYYYMMDD number so ranges of days can be read in one call.
+func IndexKey(symbol string) string {
+	return fmt.Sprintf("%s:%s:index", _pricesPrefix, normalizeSymbol(symbol))
+}
+
+// RefreshedKey returns the key the last refreshed meta data of a symbol is stored under e.g. prices:MSFT:refreshed
+func RefreshedKey(symbol string) string {
+	return fmt.Sprintf("%s:%s:refreshed", _pricesPrefix, normalizeSymbol(symbol))
+}
+
+// ActionsKey returns the key the corporate actions of a symbol are stored under e.g. prices:MSFT:actions
+func ActionsKey(symbol string) string {
+	return fmt.Sprintf("%s:%s:actions", _pricesPrefix, normalizeSymbol(symbol))
+}
+
+// dayScore converts a day in the api.Format to its sorted set score
+func dayScore(day string) (int, error) {
+	t, err := time.Parse(api.Format, day)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price date %q: %w", day, err)
+	}
+
+	return timeScore(t), nil
+}
+
+func timeScore(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+func minScore(from time.Time) interface{} {
+	if from.IsZero() {
+		return "-inf"
+	}
+
+	return timeScore(from)
+}
+
+func maxScore(to time.Time) interface{} {
+	if to.IsZero() {
+		return "+inf"
+	}
+
+	return timeScore(to)
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// contextError wraps the error of ctx into err when ctx ended the operation err is from, so a deadline or a
+// cancellation can be told apart from a failure with errors.Is
+func contextError(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if err == nil || ctxErr == nil || errors.Is(err, ctxErr) {
+		return err
+	}
+
+	return fmt.Errorf("%v: %w", err, ctxErr)
+}
+
+// AddPrices stores the prices of the symbol in one transaction so readers see all of them or none. Rather than a
+// round trip per day the commands are pipelined to redis in batches of the batch size.
+func (r *Redis) AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error {
+	if len(prices.DailyPrices) == 0 {
+		return nil
+	}
+
+	commands := make([]command, 0, len(prices.DailyPrices)+2)
+	index := redis.Args{}.Add(IndexKey(symbol))
 
+	for date, price := range prices.DailyPrices {
+		score, err := dayScore(date)
 		if err != nil {
 			return err
 		}
 
-		if res.(string) != "OK" {
-			log.Error().Err(err).Msg("add stock prices")
+		value, err := json.Marshal(price)
+		if err != nil {
+			return fmt.Errorf("add stock prices: %w", err)
 		}
+
+		commands = append(commands, command{name: "JSON.SET", args: redis.Args{PriceKey(symbol, date), ".", value}})
+		index = index.Add(score, date)
+	}
+
+	commands = append(commands, command{name: "ZADD", args: index})
+
+	if prices.MetaData.LastRefreshed != "" {
+		commands = append(commands, command{name: "SET", args: redis.Args{RefreshedKey(symbol), prices.MetaData.LastRefreshed}})
+	}
+
+	err := r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		return r.transaction(conn, commands)
+	})
+	if err != nil {
+		return fmt.Errorf("add stock prices: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Redis) GetPriceInfo(days int) ([]*api.DailyPrice, float64, error) {
-	nDaysData := make([]*api.DailyPrice, 0)
-	var totClose float64
+// command is a redis command queued in a transaction
+type command struct {
+	name string
+	args redis.Args
+}
+
+// transaction runs the commands in a MULTI/EXEC transaction. They are sent in batches of the batch size, the replies
+// of a batch being read before the next is sent, so neither a round trip is made per command nor is a large load
+// buffered whole. A transaction left open by an error is discarded when the connection goes back to the pool.
+func (r *Redis) transaction(conn redis.Conn, commands []command) error {
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+
+	// the reply to MULTI is read along with the first batch
+	pending := 1
+
+	for i, c := range commands {
+		if err := conn.Send(c.name, c.args...); err != nil {
+			return err
+		}
+
+		pending++
+
+		if pending < batchSize && i < len(commands)-1 {
+			continue
+		}
+
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+
+		for ; pending > 0; pending-- {
+			if _, err := conn.Receive(); err != nil {
+				return err
+			}
+		}
+	}
 
-	var counter int
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
 
-	// 20 years worth of daily data is the maximum
-	maxDays := int(time.Now().Sub(time.Now().AddDate(-20, 0, 0)).Hours() / 24)
+	for i, reply := range replies {
+		if err, ok := reply.(redis.Error); ok {
+			return fmt.Errorf("%s: %w", commands[i].name, err)
+		}
+	}
 
-	//TODO as an alternate insert prices as one object array and call getObject keys so we dont rely on making the key
-	for i := 1; i <= maxDays; i++ {
+	return nil
+}
+
+// GetLastRefreshed returns the "Last Refreshed" meta data of the latest prices added for the symbol, empty when
+// no prices have been added
+func (r *Redis) GetLastRefreshed(ctx context.Context, symbol string) (string, error) {
+	var refreshed string
+
+	err := r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		var err error
 
-		if counter == days {
-			break
+		refreshed, err = redis.String(conn.Do("GET", RefreshedKey(symbol)))
+		if err == redis.ErrNil {
+			return nil
 		}
 
-		key := time.Now().AddDate(0, 0, -i).Format(api.Format) // api gets up to yesterdays date of data
+		return err
+	})
+
+	return refreshed, err
+}
+
+// GetPriceInfo returns the latest days worth of prices for the symbol along with their average closing price
+func (r *Redis) GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error) {
+	return r.GetPriceRange(ctx, symbol, time.Time{}, time.Time{}, days)
+}
+
+// GetPriceRange returns the prices for the symbol between from and to inclusive, latest first, along with
+// their average closing price. A zero from or to leaves that end of the range open and days <= 0 returns every
+// day in the range. The lookup is two round trips regardless of the size of the range.
+func (r *Redis) GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error) {
+	args := redis.Args{}.Add(IndexKey(symbol), maxScore(to), minScore(from))
+	if days > 0 {
+		args = args.Add("LIMIT", 0, days)
+	}
+
+	var (
+		dates  []string
+		values [][]byte
+	)
+
+	err := r.do(ctx, func(conn redis.Conn, rh *rejson.Handler) error {
+		var err error
 
-		value, err := redis.Bytes(r.Rh.JSONGet(key, "."))
+		values = nil
+
+		dates, err = redis.Strings(conn.Do("ZREVRANGEBYSCORE", args...))
 		if err != nil {
-			log.Error().Err(err).Str("date", key)
+			return fmt.Errorf("read price index: %w", err)
 		}
 
-		if value == nil { // no data for that day
-			continue
+		if len(dates) == 0 {
+			return nil
 		}
 
-		price := api.Price{}
-		if err = json.Unmarshal(value, &price); err != nil {
-			log.Error().Err(err).Msg("unmarshal price:")
+		keys := make([]string, len(dates))
+		for i, date := range dates {
+			keys[i] = PriceKey(symbol, date)
 		}
 
-		closePrice, err := strconv.ParseFloat(price.Close, 64)
+		values, err = redis.ByteSlices(rh.JSONMGet(".", keys...))
 		if err != nil {
-			log.Error().Err(err).Msg("converting close price:")
+			return fmt.Errorf("read prices: %w", err)
+		}
 
-			return nil, 0, fmt.Errorf("converting close price: %v", err)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	nDaysData := make([]*api.DailyPrice, 0, len(dates))
+
+	var totClose api.Decimal
+
+	for i, value := range values {
+		if value == nil { // indexed but the price has since been removed
+			log.Warn().Str("symbol", symbol).Str("date", dates[i]).Msg("missing indexed price")
+
+			continue
+		}
+
+		price := api.Price{}
+		if err = json.Unmarshal(value, &price); err != nil {
+			return nil, 0, fmt.Errorf("unmarshal price: %w", err)
 		}
 
-		totClose += closePrice
+		totClose = totClose.Add(price.Close)
 
 		nDaysData = append(nDaysData, &api.DailyPrice{
-			Day:   key,
+			Day:   dates[i],
 			Price: &price,
 		})
+	}
 
-		counter++
+	if len(nDaysData) == 0 {
+		return nDaysData, 0, nil
 	}
 
 	// average close price rounded to 2 decimal places
-	avgClose := api.FixedPrecision(totClose/float64(days), 2)
+	avgClose := totClose.Div(int64(len(nDaysData)), 2).Float64()
 
 	return nDaysData, avgClose, nil
 }
+
+// AddCorporateActions merges the actions into those stored for the symbol. Actions are sparse so they are kept
+// in a single document keyed by day, each action being set under its day in one transaction so concurrent writers
+// merge rather than overwrite each other.
+func (r *Redis) AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	key := ActionsKey(symbol)
+
+	// the document is created empty unless it exists so the days can be set within it
+	commands := []command{{name: "JSON.SET", args: redis.Args{key, ".", "{}", "NX"}}}
+
+	for day, action := range actions {
+		if _, err := dayScore(day); err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(action)
+		if err != nil {
+			return fmt.Errorf("add corporate actions: %w", err)
+		}
+
+		commands = append(commands, command{name: "JSON.SET", args: redis.Args{key, fmt.Sprintf(`["%s"]`, day), value}})
+	}
+
+	err := r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		return r.transaction(conn, commands)
+	})
+	if err != nil {
+		return fmt.Errorf("add corporate actions: %w", err)
+	}
+
+	return nil
+}
+
+// GetCorporateActions returns the actions of the symbol taking effect on or after from, all of them when from is zero
+func (r *Redis) GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error) {
+	actions := make(api.CorporateActions)
+
+	var value []byte
+
+	err := r.do(ctx, func(_ redis.Conn, rh *rejson.Handler) error {
+		var err error
+
+		value, err = redis.Bytes(rh.JSONGet(ActionsKey(symbol), "."))
+
+		return err
+	})
+	if err == redis.ErrNil {
+		return actions, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read corporate actions: %w", err)
+	}
+
+	if err = json.Unmarshal(value, &actions); err != nil {
+		return nil, fmt.Errorf("unmarshal corporate actions: %w", err)
+	}
+
+	if !from.IsZero() {
+		for day := range actions {
+			if day < from.Format(api.Format) {
+				delete(actions, day)
+			}
+		}
+	}
+
+	return actions, nil
+}
+
+// Ping checks redis can be reached
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		_, err := conn.Do("PING")
+
+		return err
+	})
+}
+
+// MigrateBareKeys moves prices stored under bare date keys (e.g. 2022-04-01) by older versions of the service
+// to the keys scoped by symbol and indexes them. Bare keys carry no symbol so the caller states which symbol they belong to.
+// Keys whose scoped counterpart already exists are left untouched. It returns the number of keys moved.
+func (r *Redis) MigrateBareKeys(ctx context.Context, symbol string) (int, error) {
+	var migrated int
+
+	err := r.do(ctx, func(conn redis.Conn, _ *rejson.Handler) error {
+		cursor := 0
+		for {
+			values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", _bareKeyPattern, "COUNT", 1000))
+			if err != nil {
+				return fmt.Errorf("scan bare keys: %w", err)
+			}
+
+			var keys []string
+			if _, err = redis.Scan(values, &cursor, &keys); err != nil {
+				return fmt.Errorf("scan bare keys: %w", err)
+			}
+
+			for _, key := range keys {
+				// the pattern also matches digits that are no date, those keys are not ours to move
+				score, err := dayScore(key)
+				if err != nil {
+					log.Warn().Err(err).Str("symbol", symbol).Str("key", key).Msg("bare key is not a date, left in place")
+
+					continue
+				}
+
+				moved, err := r.migrateKey(conn, symbol, key, score)
+				if err != nil {
+					return fmt.Errorf("migrate key %s: %w", key, err)
+				}
+
+				if !moved {
+					log.Warn().Str("symbol", symbol).Str("date", key).Msg("scoped price already exists, bare key left in place")
+
+					continue
+				}
+
+				migrated++
+			}
+
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+
+	return migrated, err
+}
+
+// migrateKey renames the bare key to its scoped counterpart and indexes it in one transaction, so a key is never moved
+// without being indexed. It reports whether the key was moved, the index being left as is when the scoped key exists.
+func (r *Redis) migrateKey(conn redis.Conn, symbol, key string, score int) (bool, error) {
+	if err := conn.Send("MULTI"); err != nil {
+		return false, err
+	}
+
+	if err := conn.Send("RENAMENX", key, PriceKey(symbol, key)); err != nil {
+		return false, err
+	}
+
+	// the score is that of the day so indexing a key already indexed changes nothing
+	if err := conn.Send("ZADD", IndexKey(symbol), score, key); err != nil {
+		return false, err
+	}
+
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return false, err
+	}
+
+	if len(replies) != 2 {
+		return false, fmt.Errorf("unexpected transaction replies %v", replies)
+	}
+
+	if _, err = redis.Int(replies[1], nil); err != nil {
+		return false, fmt.Errorf("index: %w", err)
+	}
+
+	return redis.Bool(replies[0], nil)
+}
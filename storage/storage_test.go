@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+)
+
+func TestRedis_AddPrices(t *testing.T) {
+	price := api.Price{
+		Open:   api.MustParseDecimal("129.6600"),
+		High:   api.MustParseDecimal("130.2700"),
+		Low:    api.MustParseDecimal("128.0600"),
+		Close:  api.MustParseDecimal("130.1500"),
+		Volume: 4012373,
+	}
+
+	series := func(days int) api.TimeSeriesDaily {
+		daily := make(api.TimeSeriesDaily, days)
+		first := time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		for i := 0; i < days; i++ {
+			daily[first.AddDate(0, 0, i).Format(api.Format)] = price
+		}
+
+		return daily
+	}
+
+	invalid := series(10)
+	invalid["04/01/2022"] = price
+
+	tests := []struct {
+		name      string
+		batchSize int
+		prices    *api.JSONResponse
+		wantErr   bool
+		// want are the number of prices set followed by the commands after them
+		wantPrices int
+		want       []string
+	}{
+		{
+			name:       "a load smaller than a batch",
+			batchSize:  100,
+			prices:     &api.JSONResponse{MetaData: api.MD{LastRefreshed: "2022-04-01"}, DailyPrices: series(10)},
+			wantPrices: 10,
+			want:       []string{"ZADD", "SET"},
+		},
+		{
+			name:       "a load of many batches",
+			batchSize:  100,
+			prices:     &api.JSONResponse{MetaData: api.MD{LastRefreshed: "2022-04-01"}, DailyPrices: series(20 * 252)},
+			wantPrices: 20 * 252,
+			want:       []string{"ZADD", "SET"},
+		},
+		{
+			name:       "the default batch size",
+			prices:     &api.JSONResponse{DailyPrices: series(2500)},
+			wantPrices: 2500,
+			want:       []string{"ZADD"},
+		},
+		{
+			name:      "nothing is written when a day is invalid",
+			batchSize: 100,
+			prices:    &api.JSONResponse{DailyPrices: invalid},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeRedis(t)
+
+			pool := NewPool(fake.addr, "", WithConnectTimeout(time.Second), WithReadTimeout(time.Second),
+				WithWriteTimeout(time.Second))
+			defer pool.Close()
+
+			r := &Redis{Pool: pool, BatchSize: tt.batchSize}
+
+			err := r.AddPrices(context.Background(), "IBM", tt.prices)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			fake.mu.Lock()
+			executed := fake.executed
+			fake.mu.Unlock()
+
+			if !assert.Len(t, executed, tt.wantPrices+len(tt.want)) {
+				return
+			}
+
+			for _, name := range executed[:tt.wantPrices] {
+				assert.Equal(t, "JSON.SET", name)
+			}
+
+			assert.Equal(t, tt.want, executed[tt.wantPrices:])
+		})
+	}
+}
+
+func TestRedis_AddCorporateActions(t *testing.T) {
+	fake := startFakeRedis(t)
+
+	pool := NewPool(fake.addr, "", WithConnectTimeout(time.Second), WithReadTimeout(time.Second),
+		WithWriteTimeout(time.Second))
+	defer pool.Close()
+
+	r := &Redis{Pool: pool}
+
+	err := r.AddCorporateActions(context.Background(), "IBM", api.CorporateActions{
+		"2020-08-07": {DividendAmount: api.MustParseDecimal("0.8200"), Factor: 0.9982},
+		"2020-08-31": {SplitCoefficient: api.MustParseDecimal("4.0"), Factor: 0.25},
+	})
+	assert.NoError(t, err)
+
+	// the document is created unless it exists and both days are set within it in a single transaction
+	fake.mu.Lock()
+	assert.Equal(t, []string{"JSON.SET", "JSON.SET", "JSON.SET"}, fake.executed)
+	fake.mu.Unlock()
+
+	// nothing is written when a day is invalid
+	err = r.AddCorporateActions(context.Background(), "IBM", api.CorporateActions{"04/01/2022": {Factor: 0.25}})
+	assert.Error(t, err)
+
+	fake.mu.Lock()
+	assert.Len(t, fake.executed, 3)
+	fake.mu.Unlock()
+}
+
+func TestRedis_MigrateBareKeys(t *testing.T) {
+	fake := startFakeRedis(t)
+	fake.keys = []string{"1999-01-04", "2022-13-45"}
+
+	pool := NewPool(fake.addr, "", WithConnectTimeout(time.Second), WithReadTimeout(time.Second),
+		WithWriteTimeout(time.Second))
+	defer pool.Close()
+
+	r := &Redis{Pool: pool}
+
+	migrated, err := r.MigrateBareKeys(context.Background(), "IBM")
+	assert.NoError(t, err)
+
+	// the key that is no date is left in place, the other is moved and indexed in a single transaction
+	assert.Equal(t, 1, migrated)
+
+	fake.mu.Lock()
+	assert.Equal(t, []string{"RENAMENX", "ZADD"}, fake.executed)
+	fake.mu.Unlock()
+}
+
+func TestRedis_AddBars(t *testing.T) {
+	latest := time.Date(2022, 4, 1, 20, 0, 0, 0, time.UTC)
+
+	bars := func(n int) []*api.IntradayBar {
+		bars := make([]*api.IntradayBar, n)
+		for i := range bars {
+			bars[i] = &api.IntradayBar{Timestamp: latest.Add(-time.Duration(i) * time.Minute)}
+		}
+
+		return bars
+	}
+
+	tests := []struct {
+		name      string
+		batchSize int
+		bars      []*api.IntradayBar
+		// want are the commands after the bars are set
+		want []string
+	}{
+		{
+			name:      "a full load of 1min bars",
+			batchSize: 100,
+			bars:      bars(30 * 390),
+			want:      []string{"ZADD", "SET"},
+		},
+		{
+			name: "bars older than the retention window are dropped",
+			bars: append(bars(2), &api.IntradayBar{Timestamp: latest.Add(-DefaultIntradayRetention - time.Hour)}),
+			want: []string{"ZADD", "DEL", "ZREMRANGEBYSCORE", "SET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeRedis(t)
+
+			pool := NewPool(fake.addr, "", WithConnectTimeout(time.Second), WithReadTimeout(time.Second),
+				WithWriteTimeout(time.Second))
+			defer pool.Close()
+
+			r := &Redis{Pool: pool, BatchSize: tt.batchSize}
+
+			assert.NoError(t, r.AddBars(context.Background(), "IBM", api.OneMinute, tt.bars))
+
+			fake.mu.Lock()
+			executed := fake.executed
+			fake.mu.Unlock()
+
+			// every bar is set within a single transaction
+			if !assert.Len(t, executed, len(tt.bars)+len(tt.want)) {
+				return
+			}
+
+			for _, name := range executed[:len(tt.bars)] {
+				assert.Equal(t, "JSON.SET", name)
+			}
+
+			assert.Equal(t, tt.want, executed[len(tt.bars):])
+		})
+	}
+}
@@ -0,0 +1,513 @@
+// Package storagetest is the conformance suite every storage.Storage implementation must pass so they can be swapped
+// for one another:
+//
+//	func TestMemory(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Storage {
+//			return storage.NewMemory(storage.WithMemoryIntradayRetention(storagetest.IntradayRetention))
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/api"
+	"stock_ticker/storage"
+)
+
+// IntradayRetention is the retention of intraday bars the storages under test must be configured with
+const IntradayRetention = time.Hour
+
+var (
+	// run tells the symbols of a run apart from those a previous run left in a storage that outlives it
+	run = strconv.FormatInt(time.Now().UnixNano(), 36)
+	// symbols numbers the symbols of a run
+	symbols int64
+)
+
+// Run runs the suite against the storages newStorage returns, one per test. Every test uses its own symbol so a
+// storage shared between tests, such as a redis, need not be emptied.
+func Run(t *testing.T, newStorage func(t *testing.T) storage.Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Storage, symbol string)
+	}{
+		{name: "prices are read latest first with their average close", test: testPriceInfo},
+		{name: "a symbol with nothing stored reads empty", test: testUnknownSymbol},
+		{name: "symbols are case and space insensitive", test: testSymbolCase},
+		{name: "prices are read within a range", test: testPriceRange},
+		{name: "prices added again replace those of the same day", test: testOverwrite},
+		{name: "last refreshed is kept when not given", test: testLastRefreshed},
+		{name: "no prices leaves nothing stored", test: testNoPrices},
+		{name: "an invalid day is rejected", test: testInvalidDay},
+		{name: "corporate actions are merged", test: testCorporateActions},
+		{name: "bars are read latest first within the retention", test: testBars},
+		{name: "a symbol with no bars reads empty", test: testNoBars},
+		{name: "ping", test: testPing},
+		{name: "concurrent reads and writes", test: testConcurrency},
+		{name: "an ended context fails every operation", test: testCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t), symbol())
+		})
+	}
+}
+
+func symbol() string {
+	return fmt.Sprintf("CONFORMANCE%s%d", run, atomic.AddInt64(&symbols, 1))
+}
+
+// ibm is three days of IBM prices
+func ibm() api.TimeSeriesDaily {
+	return api.TimeSeriesDaily{
+		"2022-04-01": {
+			Open:   api.MustParseDecimal("309.3700"),
+			High:   api.MustParseDecimal("310.1300"),
+			Low:    api.MustParseDecimal("305.5400"),
+			Close:  api.MustParseDecimal("309.4200"),
+			Volume: 27110529,
+		},
+		"2022-03-31": {
+			Open:   api.MustParseDecimal("313.9000"),
+			High:   api.MustParseDecimal("315.1400"),
+			Low:    api.MustParseDecimal("307.8900"),
+			Close:  api.MustParseDecimal("308.3100"),
+			Volume: 33422070,
+		},
+		"2022-03-30": {
+			Open:   api.MustParseDecimal("313.7600"),
+			High:   api.MustParseDecimal("315.9500"),
+			Low:    api.MustParseDecimal("311.5800"),
+			Close:  api.MustParseDecimal("313.8600"),
+			Volume: 28163555,
+		},
+	}
+}
+
+// dailyPrices returns the days of the series, in the order given
+func dailyPrices(series api.TimeSeriesDaily, days ...string) []*api.DailyPrice {
+	prices := make([]*api.DailyPrice, len(days))
+
+	for i, day := range days {
+		price := series[day]
+		prices[i] = &api.DailyPrice{Day: day, Price: &price}
+	}
+
+	return prices
+}
+
+func day(s string) time.Time {
+	t, err := time.Parse(api.Format, s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func testPriceInfo(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{
+		MetaData:    api.MD{Symbol: symbol, LastRefreshed: "2022-04-01"},
+		DailyPrices: ibm(),
+	}))
+
+	prices, avgClose, err := s.GetPriceInfo(ctx, symbol, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, dailyPrices(ibm(), "2022-04-01", "2022-03-31"), prices)
+	assert.Equal(t, 308.87, avgClose)
+
+	prices, avgClose, err = s.GetPriceInfo(ctx, symbol, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, dailyPrices(ibm(), "2022-04-01", "2022-03-31", "2022-03-30"), prices)
+	assert.Equal(t, 310.53, avgClose)
+
+	prices, _, err = s.GetPriceInfo(ctx, symbol, 10)
+	assert.NoError(t, err)
+	assert.Len(t, prices, 3)
+
+	refreshed, err := s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01", refreshed)
+}
+
+func testUnknownSymbol(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	prices, avgClose, err := s.GetPriceInfo(ctx, symbol, 10)
+	assert.NoError(t, err)
+	assert.NotNil(t, prices)
+	assert.Empty(t, prices)
+	assert.Zero(t, avgClose)
+
+	refreshed, err := s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Empty(t, refreshed)
+
+	actions, err := s.GetCorporateActions(ctx, symbol, time.Time{})
+	assert.NoError(t, err)
+	assert.NotNil(t, actions)
+	assert.Empty(t, actions)
+}
+
+func testSymbolCase(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, " "+symbol+" ", &api.JSONResponse{
+		MetaData:    api.MD{LastRefreshed: "2022-04-01"},
+		DailyPrices: ibm(),
+	}))
+
+	prices, _, err := s.GetPriceInfo(ctx, symbol, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, dailyPrices(ibm(), "2022-04-01"), prices)
+
+	refreshed, err := s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01", refreshed)
+}
+
+func testPriceRange(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: ibm()}))
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		days     int
+		want     []string
+		avgClose float64
+	}{
+		{
+			name:     "a single day",
+			from:     day("2022-03-30"),
+			to:       day("2022-03-30"),
+			want:     []string{"2022-03-30"},
+			avgClose: 313.86,
+		},
+		{
+			name:     "bounds are inclusive",
+			from:     day("2022-03-31"),
+			to:       day("2022-04-01"),
+			want:     []string{"2022-04-01", "2022-03-31"},
+			avgClose: 308.87,
+		},
+		{
+			name:     "open start",
+			to:       day("2022-03-31"),
+			want:     []string{"2022-03-31", "2022-03-30"},
+			avgClose: 311.09,
+		},
+		{
+			name:     "open end limited to the latest day",
+			from:     day("2022-03-30"),
+			days:     1,
+			want:     []string{"2022-04-01"},
+			avgClose: 309.42,
+		},
+		{
+			name:     "bounds beyond the days stored",
+			from:     day("2022-01-03"),
+			to:       day("2022-12-30"),
+			want:     []string{"2022-04-01", "2022-03-31", "2022-03-30"},
+			avgClose: 310.53,
+		},
+		{
+			name: "no day in the range",
+			from: day("2022-04-02"),
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, avgClose, err := s.GetPriceRange(ctx, symbol, tt.from, tt.to, tt.days)
+			assert.NoError(t, err)
+			assert.Equal(t, dailyPrices(ibm(), tt.want...), prices)
+			assert.Equal(t, tt.avgClose, avgClose)
+		})
+	}
+}
+
+func testOverwrite(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: ibm()}))
+
+	corrected := api.TimeSeriesDaily{
+		"2022-04-01": {
+			Open:   api.MustParseDecimal("309.3700"),
+			High:   api.MustParseDecimal("310.1300"),
+			Low:    api.MustParseDecimal("305.5400"),
+			Close:  api.MustParseDecimal("309.5000"),
+			Volume: 27110600,
+		},
+	}
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: corrected}))
+
+	prices, _, err := s.GetPriceInfo(ctx, symbol, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, append(dailyPrices(corrected, "2022-04-01"), dailyPrices(ibm(), "2022-03-31", "2022-03-30")...), prices)
+}
+
+func testLastRefreshed(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{
+		MetaData:    api.MD{LastRefreshed: "2022-04-01"},
+		DailyPrices: ibm(),
+	}))
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: ibm()}))
+
+	refreshed, err := s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01", refreshed)
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{
+		MetaData:    api.MD{LastRefreshed: "2022-04-01 16:00:01"},
+		DailyPrices: ibm(),
+	}))
+
+	refreshed, err = s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-04-01 16:00:01", refreshed)
+}
+
+func testNoPrices(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{MetaData: api.MD{LastRefreshed: "2022-04-01"}}))
+
+	refreshed, err := s.GetLastRefreshed(ctx, symbol)
+	assert.NoError(t, err)
+	assert.Empty(t, refreshed)
+
+	assert.NoError(t, s.AddCorporateActions(ctx, symbol, nil))
+	assert.NoError(t, s.AddBars(ctx, symbol, api.FiveMinutes, nil))
+
+	_, refreshedBars, err := s.GetBars(ctx, symbol, api.FiveMinutes, 10)
+	assert.NoError(t, err)
+	assert.True(t, refreshedBars.IsZero())
+}
+
+func testInvalidDay(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	err := s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: api.TimeSeriesDaily{"04/01/2022": ibm()["2022-04-01"]}})
+	assert.Error(t, err)
+}
+
+func testCorporateActions(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	split := api.CorporateAction{SplitCoefficient: api.MustParseDecimal("4.0"), Factor: 0.25}
+	dividend := api.CorporateAction{DividendAmount: api.MustParseDecimal("0.8200"), Factor: 0.9982}
+
+	assert.NoError(t, s.AddCorporateActions(ctx, symbol, api.CorporateActions{"2020-08-07": dividend}))
+	assert.NoError(t, s.AddCorporateActions(ctx, symbol, api.CorporateActions{"2020-08-31": split}))
+
+	actions, err := s.GetCorporateActions(ctx, symbol, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, api.CorporateActions{"2020-08-07": dividend, "2020-08-31": split}, actions)
+
+	actions, err = s.GetCorporateActions(ctx, symbol, day("2020-08-10"))
+	assert.NoError(t, err)
+	assert.Equal(t, api.CorporateActions{"2020-08-31": split}, actions)
+
+	// the day an action takes effect is included
+	actions, err = s.GetCorporateActions(ctx, symbol, day("2020-08-31"))
+	assert.NoError(t, err)
+	assert.Equal(t, api.CorporateActions{"2020-08-31": split}, actions)
+}
+
+func testBars(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	latest := time.Date(2022, 4, 1, 20, 0, 0, 0, time.UTC)
+
+	bar := func(at time.Time, closing string) *api.IntradayBar {
+		price := api.MustParseDecimal(closing)
+
+		return &api.IntradayBar{
+			Timestamp: at,
+			Price:     &api.Price{Open: price, High: price, Low: price, Close: price, Volume: 100},
+		}
+	}
+
+	bars := []*api.IntradayBar{
+		bar(latest.Add(-IntradayRetention), "130.1500"),
+		bar(latest, "130.1000"),
+		// outside of the retention window
+		bar(latest.Add(-IntradayRetention-time.Minute), "129.9000"),
+	}
+
+	assert.NoError(t, s.AddBars(ctx, symbol, api.OneMinute, bars))
+
+	cached, refreshed, err := s.GetBars(ctx, symbol, api.OneMinute, 10)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), refreshed, time.Minute)
+
+	if !assert.Len(t, cached, 2) {
+		return
+	}
+
+	assert.True(t, latest.Equal(cached[0].Timestamp))
+	assert.Equal(t, bars[1].Price, cached[0].Price)
+	assert.True(t, latest.Add(-IntradayRetention).Equal(cached[1].Timestamp))
+	assert.Equal(t, bars[0].Price, cached[1].Price)
+
+	cached, _, err = s.GetBars(ctx, symbol, api.OneMinute, 1)
+	assert.NoError(t, err)
+	if !assert.Len(t, cached, 1) {
+		return
+	}
+
+	assert.True(t, latest.Equal(cached[0].Timestamp))
+
+	// n <= 0 returns every bar
+	for _, n := range []int{0, -1} {
+		cached, _, err = s.GetBars(ctx, symbol, api.OneMinute, n)
+		assert.NoError(t, err)
+		if !assert.Len(t, cached, 2, "n=%d", n) {
+			return
+		}
+
+		assert.True(t, latest.Equal(cached[0].Timestamp), "n=%d", n)
+		assert.True(t, latest.Add(-IntradayRetention).Equal(cached[1].Timestamp), "n=%d", n)
+	}
+
+	// a later bar moves the retention window along
+	assert.NoError(t, s.AddBars(ctx, symbol, api.OneMinute, []*api.IntradayBar{bar(latest.Add(time.Minute), "130.2000")}))
+
+	cached, _, err = s.GetBars(ctx, symbol, api.OneMinute, 10)
+	assert.NoError(t, err)
+	if !assert.Len(t, cached, 2) {
+		return
+	}
+
+	assert.True(t, latest.Add(time.Minute).Equal(cached[0].Timestamp))
+	assert.True(t, latest.Equal(cached[1].Timestamp))
+
+	// intervals are kept apart
+	cached, refreshed, err = s.GetBars(ctx, symbol, api.FiveMinutes, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, cached)
+	assert.True(t, refreshed.IsZero())
+}
+
+func testNoBars(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	cached, refreshed, err := s.GetBars(ctx, symbol, api.OneMinute, 10)
+	assert.NoError(t, err)
+	assert.NotNil(t, cached)
+	assert.Empty(t, cached)
+	assert.True(t, refreshed.IsZero())
+}
+
+func testPing(t *testing.T, s storage.Storage, _ string) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.Ping(ctx))
+}
+
+func testConcurrency(t *testing.T, s storage.Storage, symbol string) {
+	ctx := context.Background()
+
+	const writers = 8
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			series := api.TimeSeriesDaily{day("2022-03-01").AddDate(0, 0, i).Format(api.Format): ibm()["2022-04-01"]}
+			assert.NoError(t, s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: series}))
+
+			_, _, err := s.GetPriceInfo(ctx, symbol, writers)
+			assert.NoError(t, err)
+
+			_, err = s.GetLastRefreshed(ctx, symbol)
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	prices, avgClose, err := s.GetPriceInfo(ctx, symbol, 0)
+	assert.NoError(t, err)
+	assert.Len(t, prices, writers)
+	assert.Equal(t, 309.42, avgClose)
+}
+
+func testCancelled(t *testing.T, s storage.Storage, symbol string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	price := ibm()["2022-04-01"]
+	bar := &api.IntradayBar{Timestamp: time.Date(2022, 4, 1, 20, 0, 0, 0, time.UTC), Price: &price}
+
+	operations := []struct {
+		name string
+		op   func() error
+	}{
+		{name: "AddPrices", op: func() error {
+			return s.AddPrices(ctx, symbol, &api.JSONResponse{DailyPrices: ibm()})
+		}},
+		{name: "GetPriceInfo", op: func() error {
+			_, _, err := s.GetPriceInfo(ctx, symbol, 3)
+			return err
+		}},
+		{name: "GetPriceRange", op: func() error {
+			_, _, err := s.GetPriceRange(ctx, symbol, day("2022-03-30"), day("2022-04-01"), 0)
+			return err
+		}},
+		{name: "GetLastRefreshed", op: func() error {
+			_, err := s.GetLastRefreshed(ctx, symbol)
+			return err
+		}},
+		{name: "AddCorporateActions", op: func() error {
+			return s.AddCorporateActions(ctx, symbol, api.CorporateActions{"2020-08-31": {Factor: 0.25}})
+		}},
+		{name: "GetCorporateActions", op: func() error {
+			_, err := s.GetCorporateActions(ctx, symbol, time.Time{})
+			return err
+		}},
+		{name: "AddBars", op: func() error {
+			return s.AddBars(ctx, symbol, api.OneMinute, []*api.IntradayBar{bar})
+		}},
+		{name: "GetBars", op: func() error {
+			_, _, err := s.GetBars(ctx, symbol, api.OneMinute, 10)
+			return err
+		}},
+		{name: "Ping", op: func() error {
+			return s.Ping(ctx)
+		}},
+	}
+
+	for _, o := range operations {
+		err := o.op()
+		assert.True(t, errors.Is(err, context.Canceled), "%s: %v", o.name, err)
+	}
+
+	// nothing was written
+	prices, _, err := s.GetPriceInfo(context.Background(), symbol, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, prices)
+}
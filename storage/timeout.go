@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"stock_ticker/api"
+)
+
+// DefaultTimeout is how long a storage operation may take
+const DefaultTimeout = 10 * time.Second
+
+// Timeout bounds every operation of a Storage by a deadline of its own, on top of whatever the context of the caller
+// allows, so a slow storage fails the operation rather than holding up the request. An operation cut short returns
+// an error wrapping context.DeadlineExceeded.
+type Timeout struct {
+	storage Storage
+	timeout time.Duration
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Storage = (*Timeout)(nil)
+
+// NewTimeout bounds the operations of s by timeout, zero leaving them to the context of the caller
+func NewTimeout(s Storage, timeout time.Duration) *Timeout {
+	return &Timeout{storage: s, timeout: timeout}
+}
+
+// withTimeout returns the context an operation runs within
+func (t *Timeout) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+func (t *Timeout) AddPrices(ctx context.Context, symbol string, prices *api.JSONResponse) error {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, t.storage.AddPrices(ctx, symbol, prices))
+}
+
+func (t *Timeout) GetPriceInfo(ctx context.Context, symbol string, days int) ([]*api.DailyPrice, float64, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	prices, avgClose, err := t.storage.GetPriceInfo(ctx, symbol, days)
+
+	return prices, avgClose, contextError(ctx, err)
+}
+
+func (t *Timeout) GetPriceRange(ctx context.Context, symbol string, from, to time.Time, days int) ([]*api.DailyPrice, float64, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	prices, avgClose, err := t.storage.GetPriceRange(ctx, symbol, from, to, days)
+
+	return prices, avgClose, contextError(ctx, err)
+}
+
+func (t *Timeout) GetLastRefreshed(ctx context.Context, symbol string) (string, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	refreshed, err := t.storage.GetLastRefreshed(ctx, symbol)
+
+	return refreshed, contextError(ctx, err)
+}
+
+func (t *Timeout) AddCorporateActions(ctx context.Context, symbol string, actions api.CorporateActions) error {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, t.storage.AddCorporateActions(ctx, symbol, actions))
+}
+
+func (t *Timeout) GetCorporateActions(ctx context.Context, symbol string, from time.Time) (api.CorporateActions, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	actions, err := t.storage.GetCorporateActions(ctx, symbol, from)
+
+	return actions, contextError(ctx, err)
+}
+
+func (t *Timeout) AddBars(ctx context.Context, symbol string, interval api.Interval, bars []*api.IntradayBar) error {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, t.storage.AddBars(ctx, symbol, interval, bars))
+}
+
+func (t *Timeout) GetBars(ctx context.Context, symbol string, interval api.Interval, n int) ([]*api.IntradayBar, time.Time, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	bars, refreshed, err := t.storage.GetBars(ctx, symbol, interval, n)
+
+	return bars, refreshed, contextError(ctx, err)
+}
+
+func (t *Timeout) Ping(ctx context.Context) error {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, t.storage.Ping(ctx))
+}
@@ -0,0 +1,113 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"stock_ticker/storage"
+	"stock_ticker/storage/mocks"
+	"stock_ticker/storage/storagetest"
+)
+
+func TestTimeout(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewTimeout(storage.NewMemory(storage.WithMemoryIntradayRetention(storagetest.IntradayRetention)),
+			time.Second)
+	})
+}
+
+// waitForDone blocks a storage operation until its context ends, as a storage that stopped answering does
+func waitForDone(ctx context.Context, _ string) (string, error) {
+	<-ctx.Done()
+
+	return "", errors.New("connection closed")
+}
+
+func TestTimeout_deadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// ctx is the context of the caller
+		ctx      func() (context.Context, context.CancelFunc)
+		outcome  func(s *mock_storage.MockStorage)
+		wantErr  error
+		wantFail bool
+	}{
+		{
+			name:    "an operation outliving the timeout is cut short",
+			timeout: 10 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			outcome: func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetLastRefreshed(gomock.Any(), "IBM").Times(1).DoAndReturn(waitForDone)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "the deadline of the caller applies when it is sooner",
+			timeout: time.Hour,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			outcome: func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetLastRefreshed(gomock.Any(), "IBM").Times(1).DoAndReturn(waitForDone)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "a caller cancelling is told apart from a deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+
+				return ctx, cancel
+			},
+			outcome: func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetLastRefreshed(gomock.Any(), "IBM").Times(1).DoAndReturn(waitForDone)
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "a failure of the storage is not a timeout",
+			timeout: time.Hour,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			outcome: func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetLastRefreshed(gomock.Any(), "IBM").Times(1).Return("", errors.New("connection refused"))
+			},
+			wantFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			storageMock := mock_storage.NewMockStorage(mockController)
+			tt.outcome(storageMock)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, err := storage.NewTimeout(storageMock, tt.timeout).GetLastRefreshed(ctx, "IBM")
+
+			if tt.wantFail {
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, context.DeadlineExceeded), err)
+				assert.False(t, errors.Is(err, context.Canceled), err)
+
+				return
+			}
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}